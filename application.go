@@ -9,6 +9,7 @@ import (
 	"github.com/misakacoder/inuyasha/consts"
 	"github.com/misakacoder/inuyasha/http/resp"
 	"github.com/misakacoder/inuyasha/middleware"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
 	innerLogger "github.com/misakacoder/inuyasha/pkg/logger"
 	"github.com/misakacoder/kagome/cond"
 	"github.com/misakacoder/kagome/net"
@@ -81,6 +82,7 @@ func (application *application) Serve() {
 	application.once.Do(func() {
 		application.listenConfig()
 		application.initLogger()
+		application.initDateTime()
 		appName := application.AppName
 		logger.Info("The %s version is %s and the build time is %s", appName, application.Version, application.BuildTime)
 		before := application.before
@@ -139,6 +141,13 @@ func (application *application) initLogger() {
 	logger.SetLevel(level)
 }
 
+func (application *application) initDateTime() {
+	conf := configs.Config.DateTime
+	if err := types.SetDateTimeFormat(conf.Format, conf.Zone); err != nil {
+		logger.Panic("invalid dateTime config: %s", err.Error())
+	}
+}
+
 func (application *application) listenConfig() {
 	for _, listener := range application.listeners {
 		configs.AddListener(listener.Config, listener.Reload)
@@ -5,6 +5,8 @@ import (
 	_ "embed"
 	"github.com/jinzhu/configor"
 	"github.com/misakacoder/inuyasha/pkg/db/orm"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"github.com/misakacoder/inuyasha/pkg/jwt"
 	"github.com/misakacoder/kagome/file"
 	"github.com/misakacoder/kagome/maps"
 	"github.com/misakacoder/kagome/str"
@@ -28,6 +30,9 @@ var (
 		Reload: func(config any) {
 			level, _ := logger.Parse(Config.Log.Level)
 			logger.SetLevel(level)
+			if err := types.SetDateTimeFormat(Config.DateTime.Format, Config.DateTime.Zone); err != nil {
+				logger.Error("invalid dateTime config: %s", err.Error())
+			}
 		},
 	}}
 	once sync.Once
@@ -48,7 +53,12 @@ type configuration struct {
 		IdleTimeout       time.Duration `yaml:"idleTimeout"`
 		MaxHeaderBytes    int           `yaml:"maxHeaderBytes"`
 	}
-	Db  orm.Config
+	Db       orm.Config
+	Jwt      jwt.Config
+	DateTime struct {
+		Format string
+		Zone   string
+	} `yaml:"dateTime"`
 	Log struct {
 		Directory string
 		Level     string
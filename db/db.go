@@ -19,19 +19,26 @@ func Connect(dialector func(dsn string) gorm.Dialector) {
 		conf := configs.Config.Db
 		GORM = orm.New(dialector, conf)
 		GORM.Logger = Logger
+		if err := GORM.EnableMetrics("default"); err != nil {
+			logger.Panic(err.Error())
+		}
+		if err := GORM.EnableSlowLog("default"); err != nil {
+			logger.Panic(err.Error())
+		}
 	})
 }
 
-func Logger(level string, caller string, format string, args ...any) {
+func Logger(level string, entry orm.SQLEntry) {
 	lvl, ok := logger.Parse(level)
 	if !ok {
 		lvl = logger.DEBUG
 	}
+	format, args := entry.Format()
 	switch log := logger.GetLogger().(type) {
 	case *innerLogger.DynamicLevelLogger:
-		log.Push(lvl, caller, format, args...)
+		log.PushTables(lvl, entry.Caller, entry.Tables, format, args...)
 	case *logger.SimpleLogger:
-		log.Push(lvl, caller, format, args...)
+		log.Push(lvl, entry.Caller, format, args...)
 	default:
 		logger.Warn("unknown logger: %T", log)
 	}
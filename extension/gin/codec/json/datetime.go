@@ -0,0 +1,183 @@
+package json
+
+import (
+	"fmt"
+	"github.com/json-iterator/go"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"reflect"
+	"unsafe"
+)
+
+var (
+	dateTimeType     = reflect.TypeOf(types.DateTime{})
+	nullDateTimeType = reflect.TypeOf(types.NullDateTime{})
+)
+
+// DateTimeExtension encodes and decodes DateTime and NullDateTime fields
+// tagged with `datetime:"..."` in the layout and zone of the tag.
+type DateTimeExtension struct {
+	jsoniter.DummyExtension
+}
+
+func (extension *DateTimeExtension) UpdateStructDescriptor(structDescriptor *jsoniter.StructDescriptor) {
+	for _, binding := range structDescriptor.Fields {
+		structField := binding.Field
+		tag, ok := structField.Tag().Lookup("datetime")
+		fieldType := structField.Type().Type1()
+		if !ok || !isDateTimeType(fieldType) {
+			continue
+		}
+		format, err := types.ParseDateTimeTag(tag)
+		if err != nil {
+			err = fmt.Errorf("%s.%s: %w", structDescriptor.Type, structField.Name(), err)
+		}
+		codec := &dateTimeCodec{tp: fieldType, name: structField.Name(), format: format, err: err}
+		binding.Encoder = codec
+		binding.Decoder = codec
+	}
+}
+
+type dateTimeCodec struct {
+	tp     reflect.Type
+	name   string
+	format types.DateTimeFormat
+	err    error
+}
+
+func (codec *dateTimeCodec) IsEmpty(ptr unsafe.Pointer) bool {
+	value := reflect.NewAt(codec.tp, ptr).Elem()
+	switch value.Kind() {
+	case reflect.Pointer:
+		return value.IsNil()
+	case reflect.Slice:
+		return value.Len() == 0
+	}
+	return false
+}
+
+func (codec *dateTimeCodec) Encode(ptr unsafe.Pointer, stream *jsoniter.Stream) {
+	if codec.err != nil {
+		stream.Error = codec.err
+		return
+	}
+	codec.encode(reflect.NewAt(codec.tp, ptr).Elem(), stream)
+}
+
+func (codec *dateTimeCodec) encode(value reflect.Value, stream *jsoniter.Stream) {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			stream.WriteNil()
+			return
+		}
+		codec.encode(value.Elem(), stream)
+		return
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			stream.WriteNil()
+			return
+		}
+		stream.WriteArrayStart()
+		for i := 0; i < value.Len(); i++ {
+			if i > 0 {
+				stream.WriteMore()
+			}
+			codec.encode(value.Index(i), stream)
+		}
+		stream.WriteArrayEnd()
+		return
+	}
+	switch v := value.Interface().(type) {
+	case types.DateTime:
+		stream.WriteString(codec.format.Format(v.Time()))
+	case types.NullDateTime:
+		if !v.Valid {
+			stream.WriteNil()
+			return
+		}
+		stream.WriteString(codec.format.Format(v.Time()))
+	}
+}
+
+func (codec *dateTimeCodec) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	if codec.err != nil {
+		iter.ReportError("decode "+codec.name, codec.err.Error())
+		return
+	}
+	codec.decode(reflect.NewAt(codec.tp, ptr).Elem(), iter)
+}
+
+func (codec *dateTimeCodec) decode(value reflect.Value, iter *jsoniter.Iterator) {
+	switch iter.WhatIsNext() {
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		value.SetZero()
+		return
+	case jsoniter.ArrayValue:
+		if value.Kind() == reflect.Pointer {
+			value.Set(reflect.New(value.Type().Elem()))
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			iter.ReportError("decode "+codec.name, "unexpected array")
+			return
+		}
+		if value.Kind() == reflect.Slice {
+			value.SetLen(0)
+		}
+		i := 0
+		iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+			if value.Kind() == reflect.Slice {
+				value.Set(reflect.Append(value, reflect.Zero(value.Type().Elem())))
+			} else if i >= value.Len() {
+				iter.Skip()
+				return true
+			}
+			codec.decode(value.Index(i), iter)
+			i++
+			return iter.Error == nil
+		})
+		return
+	}
+	var text string
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		text = iter.ReadString()
+	case jsoniter.NumberValue:
+		text = iter.ReadNumber().String()
+	default:
+		iter.ReportError("decode "+codec.name, "expect string or number")
+		return
+	}
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	if value.Type() == nullDateTimeType && text == "" {
+		value.SetZero()
+		return
+	}
+	tm, err := codec.format.Parse(text)
+	if err != nil {
+		iter.ReportError("decode "+codec.name, (&types.DateTimeFieldError{Field: codec.name, Value: text}).Error())
+		return
+	}
+	switch value.Type() {
+	case dateTimeType:
+		value.Set(reflect.ValueOf(types.DateTimeFrom(tm)))
+	case nullDateTimeType:
+		value.Set(reflect.ValueOf(types.NullDateTimeFrom(tm)))
+	}
+}
+
+func isDateTimeType(tp reflect.Type) bool {
+	if tp.Kind() == reflect.Slice || tp.Kind() == reflect.Array {
+		tp = tp.Elem()
+	}
+	if tp.Kind() == reflect.Pointer {
+		tp = tp.Elem()
+	}
+	return tp == dateTimeType || tp == nullDateTimeType
+}
@@ -0,0 +1,143 @@
+package json
+
+import (
+	"github.com/gin-gonic/gin/codec/json"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"testing"
+	"time"
+)
+
+type audit struct {
+	CreatedBy string         `json:"createdBy"`
+	CreatedAt types.DateTime `json:"createdAt" datetime:"layout=2006/01/02 15:04;zone=UTC"`
+}
+
+type event struct {
+	Name     string
+	At       types.DateTime     `json:"at" datetime:"layout=2006-01-02T15:04:05Z07:00;zone=Asia/Tokyo"`
+	Day      *types.DateTime    `json:"day,omitempty" datetime:"layout=2006-01-02;zone=UTC"`
+	Closed   types.NullDateTime `json:"closed" datetime:"layout=15:04;zone=UTC"`
+	Range    []types.DateTime   `json:"range" datetime:"layout=01-02;zone=UTC"`
+	Plain    types.DateTime     `json:"plain"`
+	Children []event            `json:"children,omitempty"`
+	Extra    map[string]any     `json:"extra,omitempty"`
+	audit
+}
+
+type badTag struct {
+	At types.DateTime `json:"at" datetime:"color=red"`
+}
+
+func useUTC(t *testing.T) {
+	previous := types.GetDateTimeFormat()
+	t.Cleanup(func() {
+		_ = types.SetDateTimeFormat(previous.Layout, previous.Location.String())
+	})
+	if err := types.SetDateTimeFormat(time.DateTime, "UTC"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarshalDateTime(t *testing.T) {
+	useUTC(t)
+	at := types.DateTimeFrom(time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC))
+	day := types.DateTimeFrom(time.Date(2024, 3, 5, 23, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name    string
+		value   any
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "untagged",
+			value: struct{ At types.DateTime }{At: at},
+			want:  `{"at":"2024-03-04 05:06:07"}`,
+		},
+		{
+			name: "nested, embedded and dynamic",
+			value: map[string]any{"data": event{
+				Name:     "a",
+				At:       at,
+				Day:      &day,
+				Closed:   types.NullDateTimeFrom(day.Time()),
+				Range:    []types.DateTime{at, day},
+				Plain:    at,
+				Children: []event{{Name: "b", At: day}},
+				Extra:    map[string]any{"child": event{At: at}},
+				audit:    audit{CreatedBy: "u", CreatedAt: at},
+			}},
+			want: `{"data":{"name":"a","at":"2024-03-04T14:06:07+09:00","day":"2024-03-05","closed":"23:00","range":["03-04","03-05"],"plain":"2024-03-04 05:06:07",` +
+				`"children":[{"name":"b","at":"2024-03-06T08:00:00+09:00","closed":null,"range":null,"plain":"0001-01-01 00:00:00","createdBy":"","createdAt":"0001/01/01 00:00"}],` +
+				`"extra":{"child":{"name":"","at":"2024-03-04T14:06:07+09:00","closed":null,"range":null,"plain":"0001-01-01 00:00:00","createdBy":"","createdAt":"0001/01/01 00:00"}},` +
+				`"createdBy":"u","createdAt":"2024/03/04 05:06"}}`,
+		},
+		{
+			name:    "bad tag",
+			value:   badTag{At: at},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.API.Marshal(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("data = %s, want error", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.want {
+				t.Fatalf("got  %s\nwant %s", data, test.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalDateTime(t *testing.T) {
+	useUTC(t)
+	tests := []struct {
+		name    string
+		data    string
+		check   func(value event) bool
+		wantErr bool
+	}{
+		{
+			name: "tagged layouts",
+			data: `{"at":"2024-03-04T14:06:07+09:00","day":"2024-03-05","closed":"23:00","createdAt":"2024/03/04 05:06"}`,
+			check: func(value event) bool {
+				return value.At.Time().Equal(time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)) &&
+					value.Day.Time().Equal(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)) &&
+					value.Closed.Valid && value.Closed.Time().Hour() == 23 &&
+					value.CreatedAt.Time().Equal(time.Date(2024, 3, 4, 5, 6, 0, 0, time.UTC))
+			},
+		},
+		{
+			name: "slices, millis and nulls",
+			data: `{"range":["03-04",1709683200000],"closed":null,"day":null}`,
+			check: func(value event) bool {
+				return len(value.Range) == 2 && value.Range[1].Time().Equal(time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)) &&
+					!value.Closed.Valid && value.Day == nil
+			},
+		},
+		{
+			name:    "wrong format",
+			data:    `{"day":"03/05/2024"}`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var value event
+			err := json.API.Unmarshal([]byte(test.data), &value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, test.wantErr)
+			}
+			if err == nil && !test.check(value) {
+				t.Fatalf("value = %+v", value)
+			}
+		})
+	}
+}
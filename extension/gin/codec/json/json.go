@@ -12,6 +12,7 @@ func init() {
 		API: jsoniter.ConfigCompatibleWithStandardLibrary,
 	}
 	lowerCamelCaseJSON.API.RegisterExtension(lowerCamelCaseJSON)
+	lowerCamelCaseJSON.API.RegisterExtension(&DateTimeExtension{})
 	json.API = lowerCamelCaseJSON
 }
 
@@ -7,6 +7,7 @@ require (
 	github.com/go-playground/validator/v10 v10.29.0
 	github.com/golang-jwt/jwt/v5 v5.3.0
 	github.com/jinzhu/configor v1.2.2
+	github.com/json-iterator/go v1.1.12
 	github.com/misakacoder/kagome v0.0.0-20251231092606-905d1950f5a6
 	github.com/misakacoder/logger v0.0.0-20250717034414-5b0b327c6c16
 	github.com/xuri/excelize/v2 v2.10.0
@@ -27,7 +28,6 @@ require (
 	github.com/goccy/go-yaml v1.18.0 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
-	github.com/json-iterator/go v1.1.12 // indirect
 	github.com/klauspost/cpuid/v2 v2.3.0 // indirect
 	github.com/leodido/go-urn v1.4.0 // indirect
 	github.com/lestrrat-go/file-rotatelogs v2.4.0+incompatible // indirect
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/misakacoder/inuyasha/http/resp"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
 	"github.com/misakacoder/kagome/str"
 	"reflect"
 )
@@ -20,19 +21,19 @@ func BindUri[T any](ctx *gin.Context, object T) T {
 }
 
 func BindQuery[T any](ctx *gin.Context, object T) T {
-	return BindAny(ctx.ShouldBindQuery, object)
+	return BindAny(shouldBindWith(ctx, binding.Query), object)
 }
 
 func BindForm[T any](ctx *gin.Context, object T) T {
-	return BindAny(shouldBindForm(ctx), object)
+	return BindAny(shouldBindWith(ctx, binding.Form), object)
 }
 
 func BindJSON[T any](ctx *gin.Context, object T) T {
-	return BindAny(ctx.ShouldBindJSON, object)
+	return BindAny(shouldBindWith(ctx, binding.JSON), object)
 }
 
 func Bind[T any](ctx *gin.Context, object T) T {
-	return BindAny(ctx.ShouldBind, object)
+	return BindAny(shouldBindWith(ctx, binding.Default(ctx.Request.Method, ctx.ContentType())), object)
 }
 
 func BindAny[T any](bind func(v any) error, object T) T {
@@ -42,13 +43,11 @@ func BindAny[T any](bind func(v any) error, object T) T {
 	return object
 }
 
-func shouldBindForm(ctx *gin.Context) func(v any) error {
-	return func(v any) error {
-		return ctx.ShouldBindWith(v, binding.Form)
-	}
-}
-
 func validateError(object any, err error) {
+	var dateTimeError *types.DateTimeFieldError
+	if errors.As(err, &dateTimeError) {
+		panic(resp.ParameterError.Msg(dateTimeError.Error()))
+	}
 	var validationErrors validator.ValidationErrors
 	var sliceValidationError binding.SliceValidationError
 	if errors.As(err, &sliceValidationError) {
@@ -0,0 +1,228 @@
+package req
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMultipartMemory = 32 << 20
+
+var (
+	dateTimeFieldCache sync.Map
+	bindUnmarshaler    = reflect.TypeOf((*binding.BindUnmarshaler)(nil)).Elem()
+	dateTimeTypes      = map[reflect.Type]bool{
+		reflect.TypeOf(types.DateTime{}):     true,
+		reflect.TypeOf(types.NullDateTime{}): true,
+	}
+	collectionSeparators = map[string]string{
+		"csv":   ",",
+		"ssv":   " ",
+		"tsv":   "\t",
+		"pipes": "|",
+	}
+)
+
+type dateTimeFields struct {
+	fields []dateTimeField
+	err    error
+}
+
+type dateTimeField struct {
+	name      string
+	format    types.DateTimeFormat
+	quoted    bool
+	separator string
+}
+
+// shouldBindWith binds like ctx.ShouldBindWith, but parses DateTime fields
+// with the format of their `datetime` tag. Form values are converted on a
+// copy of the request and JSON bodies through types.NormalizeJSON, so the
+// request the handler sees is left as it was sent.
+func shouldBindWith(ctx *gin.Context, bind binding.Binding) func(v any) error {
+	return func(v any) error {
+		switch bind {
+		case binding.JSON:
+			return bindJSON(ctx, v)
+		case binding.Query, binding.Form, binding.FormPost, binding.FormMultipart:
+			return bindForm(ctx, bind, v)
+		}
+		return ctx.ShouldBindWith(v, bind)
+	}
+}
+
+func bindJSON(ctx *gin.Context, v any) error {
+	if ctx.Request == nil || ctx.Request.Body == nil {
+		return ctx.ShouldBindWith(v, binding.JSON)
+	}
+	data, err := io.ReadAll(ctx.Request.Body)
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if data, err = types.NormalizeJSON(data, reflect.TypeOf(v)); err != nil {
+		return err
+	}
+	return binding.JSON.BindBody(data, v)
+}
+
+func bindForm(ctx *gin.Context, bind binding.Binding, v any) error {
+	fields, err := formDateTimeFields(reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return ctx.ShouldBindWith(v, bind)
+	}
+	request := *ctx.Request
+	if bind == binding.Query {
+		query, err := normalizeValues(request.URL.Query(), fields)
+		if err != nil {
+			return err
+		}
+		requestURL := *request.URL
+		requestURL.RawQuery = query.Encode()
+		request.URL = &requestURL
+		return bind.Bind(&request, v)
+	}
+	if err = ctx.Request.ParseMultipartForm(defaultMultipartMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	if request.Form, err = normalizeValues(ctx.Request.Form, fields); err != nil {
+		return err
+	}
+	if request.PostForm, err = normalizeValues(ctx.Request.PostForm, fields); err != nil {
+		return err
+	}
+	if multipartForm := ctx.Request.MultipartForm; multipartForm != nil {
+		value, err := normalizeValues(multipartForm.Value, fields)
+		if err != nil {
+			return err
+		}
+		request.MultipartForm = &multipart.Form{Value: value, File: multipartForm.File}
+	}
+	return bind.Bind(&request, v)
+}
+
+// normalizeValues returns a copy of values with every DateTime field converted
+// to RFC 3339. gin hands single values to UnmarshalParam but decodes slice
+// elements as JSON, so those are quoted.
+func normalizeValues(values url.Values, fields []dateTimeField) (url.Values, error) {
+	if values == nil {
+		return nil, nil
+	}
+	normalized := make(url.Values, len(values))
+	for key, value := range values {
+		normalized[key] = append([]string(nil), value...)
+	}
+	done := map[string]bool{}
+	for _, field := range fields {
+		raw, ok := values[field.name]
+		if !ok || done[field.name] {
+			continue
+		}
+		done[field.name] = true
+		var converted []string
+		for _, value := range raw {
+			parts := []string{value}
+			if field.separator != "" {
+				parts = strings.Split(value, field.separator)
+			}
+			for _, part := range parts {
+				if part == "" {
+					converted = append(converted, part)
+					continue
+				}
+				tm, err := field.format.Parse(part)
+				if err != nil {
+					return nil, &types.DateTimeFieldError{Field: field.name, Value: part}
+				}
+				text := tm.Format(time.RFC3339Nano)
+				if field.quoted {
+					text = strconv.Quote(text)
+				}
+				converted = append(converted, text)
+			}
+		}
+		normalized[field.name] = converted
+	}
+	return normalized, nil
+}
+
+func formDateTimeFields(objectType reflect.Type) ([]dateTimeField, error) {
+	if cached, ok := dateTimeFieldCache.Load(objectType); ok {
+		result := cached.(dateTimeFields)
+		return result.fields, result.err
+	}
+	fields, err := collectDateTimeFields(objectType, map[reflect.Type]bool{})
+	dateTimeFieldCache.Store(objectType, dateTimeFields{fields: fields, err: err})
+	return fields, err
+}
+
+// collectDateTimeFields walks objectType the way gin's form mapping does:
+// embedded and nested structs share the flat form keys of their parent.
+func collectDateTimeFields(objectType reflect.Type, visiting map[reflect.Type]bool) ([]dateTimeField, error) {
+	for objectType != nil && objectType.Kind() == reflect.Pointer {
+		objectType = objectType.Elem()
+	}
+	if objectType == nil || objectType.Kind() != reflect.Struct || visiting[objectType] {
+		return nil, nil
+	}
+	visiting[objectType] = true
+	defer delete(visiting, objectType)
+	var fields []dateTimeField
+	for i := 0; i < objectType.NumField(); i++ {
+		structField := objectType.Field(i)
+		name, _, _ := strings.Cut(structField.Tag.Get("form"), ",")
+		if name == "-" || (!structField.IsExported() && !structField.Anonymous) {
+			continue
+		}
+		fieldType := structField.Type
+		collection := fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array
+		if collection {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if !dateTimeTypes[fieldType] {
+			if !collection && fieldType.Kind() == reflect.Struct && !reflect.PointerTo(fieldType).Implements(bindUnmarshaler) {
+				nested, err := collectDateTimeFields(fieldType, visiting)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, nested...)
+			}
+			continue
+		}
+		formatTag, tagged := structField.Tag.Lookup("datetime")
+		if !tagged && !collection {
+			continue
+		}
+		format, err := types.ParseDateTimeTag(formatTag)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", objectType, structField.Name, err)
+		}
+		if name == "" {
+			name = structField.Name
+		}
+		field := dateTimeField{name: name, format: format, quoted: collection}
+		if collection {
+			field.separator = collectionSeparators[structField.Tag.Get("collection_format")]
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
@@ -0,0 +1,146 @@
+package req
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/misakacoder/inuyasha/http/resp"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dateTimeFilter struct {
+	Day   types.DateTime `form:"day" json:"day" datetime:"layout=2006/01/02;zone=UTC"`
+	Range dateTimeRange  `json:"range"`
+}
+
+type dateTimeRange struct {
+	Between []types.DateTime `form:"between" json:"between" datetime:"layout=01-02-2006;zone=UTC" collection_format:"csv"`
+	Since   *types.DateTime  `form:"since" json:"since" datetime:"layout=2006-01-02 15:04;zone=Asia/Shanghai"`
+}
+
+type dateTimeBadTag struct {
+	Day types.DateTime `form:"day" datetime:"layout=2006;color=red"`
+}
+
+func TestShouldBindWithDateTimes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	since := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	between := []time.Time{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		name    string
+		bind    binding.Binding
+		request func() *http.Request
+	}{
+		{
+			name: "query",
+			bind: binding.Query,
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/?day=2024/03/04&between=01-02-2024,02-03-2024&since=2024-03-01+16:30", nil)
+			},
+		},
+		{
+			name: "urlencoded form",
+			bind: binding.Form,
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodPost, "/?day=2024/03/04", strings.NewReader("between=01-02-2024&between=02-03-2024&since=2024-03-01+16:30"))
+				request.Header.Set("Content-Type", binding.MIMEPOSTForm)
+				return request
+			},
+		},
+		{
+			name: "multipart form",
+			bind: binding.FormMultipart,
+			request: func() *http.Request {
+				body := &bytes.Buffer{}
+				writer := multipart.NewWriter(body)
+				_ = writer.WriteField("day", "2024/03/04")
+				_ = writer.WriteField("between", "01-02-2024,02-03-2024")
+				_ = writer.WriteField("since", "2024-03-01 16:30")
+				_ = writer.Close()
+				request := httptest.NewRequest(http.MethodPost, "/", body)
+				request.Header.Set("Content-Type", writer.FormDataContentType())
+				return request
+			},
+		},
+		{
+			name: "nested json",
+			bind: binding.JSON,
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"day":"2024/03/04","range":{"between":["01-02-2024","02-03-2024"],"since":"2024-03-01 16:30"}}`))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = test.request()
+			rawQuery := ctx.Request.URL.RawQuery
+			var filter dateTimeFilter
+			if err := shouldBindWith(ctx, test.bind)(&filter); err != nil {
+				t.Fatal(err)
+			}
+			if !filter.Day.Time().Equal(day) {
+				t.Errorf("day = %v, want %v", filter.Day.Time(), day)
+			}
+			if filter.Range.Since == nil || !filter.Range.Since.Time().Equal(since) {
+				t.Errorf("since = %v, want %v", filter.Range.Since, since)
+			}
+			if len(filter.Range.Between) != 2 || !filter.Range.Between[0].Time().Equal(between[0]) || !filter.Range.Between[1].Time().Equal(between[1]) {
+				t.Errorf("between = %v, want %v", filter.Range.Between, between)
+			}
+			if ctx.Request.URL.RawQuery != rawQuery {
+				t.Errorf("request query rewritten to %q", ctx.Request.URL.RawQuery)
+			}
+		})
+	}
+}
+
+func TestShouldBindWithDateTimeErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		object    any
+		target    string
+		wantField string
+	}{
+		{name: "query", object: &dateTimeFilter{}, target: "/?day=03/04/2024", wantField: "day"},
+		{name: "nested", object: &dateTimeFilter{}, target: "/?between=01-02-2024,x", wantField: "between"},
+		{name: "bad tag", object: &dateTimeBadTag{}, target: "/?day=2024"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodGet, test.target, nil)
+			err := shouldBindWith(ctx, binding.Query)(test.object)
+			var fieldError *types.DateTimeFieldError
+			if test.wantField == "" {
+				if err == nil || errors.As(err, &fieldError) || !strings.Contains(err.Error(), "color=red") {
+					t.Fatalf("err = %v, want tag error", err)
+				}
+				return
+			}
+			if !errors.As(err, &fieldError) || fieldError.Field != test.wantField {
+				t.Fatalf("err = %v, want field %s", err, test.wantField)
+			}
+		})
+	}
+}
+
+func TestBindQueryDateTimeError(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?day=x", nil)
+	defer func() {
+		result, ok := recover().(resp.Result)
+		if !ok || result.Code != resp.ParameterError.Code || result.Message != "day的时间格式不正确" {
+			t.Fatalf("recovered %+v", result)
+		}
+	}()
+	BindQuery(ctx, &dateTimeFilter{})
+}
@@ -0,0 +1,47 @@
+package resp
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+const attrChars = "!#$&+-.^_`|~"
+
+func Attachment(ctx *gin.Context, filename string, contentType string) {
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", ContentDisposition("attachment", filename))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+}
+
+func ContentDisposition(disposition string, filename string) string {
+	fallback := strings.Builder{}
+	encoded := strings.Builder{}
+	ascii := true
+	for _, r := range filename {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			fallback.WriteByte('_')
+		case r > 0x7e:
+			ascii = false
+			fallback.WriteByte('_')
+		case r == '"' || r == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(r)
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+	if ascii {
+		return fmt.Sprintf(`%s; filename="%s"`, disposition, fallback.String())
+	}
+	for _, b := range []byte(filename) {
+		if b < 0x80 && (b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || strings.IndexByte(attrChars, b) >= 0) {
+			encoded.WriteByte(b)
+		} else {
+			encoded.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, disposition, fallback.String(), encoded.String())
+}
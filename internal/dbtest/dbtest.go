@@ -0,0 +1,312 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const driverName = "inuyasha-dbtest"
+
+var (
+	registerOnce sync.Once
+	recorders    sync.Map
+	sequence     int
+	sequenceLock sync.Mutex
+)
+
+type Statement struct {
+	SQL  string
+	Args []any
+}
+
+type Result struct {
+	Columns []string
+	Rows    [][]driver.Value
+}
+
+type Recorder struct {
+	mutex      sync.Mutex
+	statements []Statement
+	Query      func(query string, args []any) Result
+	Fail       func(query string, args []any) error
+}
+
+func (recorder *Recorder) Statements() []Statement {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]Statement{}, recorder.statements...)
+}
+
+func (recorder *Recorder) SQL() []string {
+	var result []string
+	for _, statement := range recorder.Statements() {
+		result = append(result, statement.SQL)
+	}
+	return result
+}
+
+func (recorder *Recorder) Reset() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.statements = nil
+}
+
+func (recorder *Recorder) record(query string, args []driver.NamedValue) ([]any, error) {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	recorder.mutex.Lock()
+	recorder.statements = append(recorder.statements, Statement{SQL: query, Args: values})
+	recorder.mutex.Unlock()
+	if recorder.Fail != nil {
+		if err := recorder.Fail(query, values); err != nil {
+			return values, err
+		}
+	}
+	return values, nil
+}
+
+func Open(t testing.TB, dialect string) (*gorm.DB, *Recorder) {
+	registerOnce.Do(func() {
+		sql.Register(driverName, sqlDriver{})
+	})
+	sequenceLock.Lock()
+	sequence++
+	dsn := strconv.Itoa(sequence)
+	sequenceLock.Unlock()
+	recorder := &Recorder{}
+	recorders.Store(dsn, recorder)
+	sqlDB, err := sql.Open(driverName, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(Dialector{Dialect: dialect, Conn: sqlDB}, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+		recorders.Delete(dsn)
+	})
+	return db, recorder
+}
+
+type Dialector struct {
+	Dialect string
+	Conn    gorm.ConnPool
+}
+
+func (dialector Dialector) Name() string {
+	return dialector.Dialect
+}
+
+func (dialector Dialector) Initialize(db *gorm.DB) error {
+	db.ConnPool = dialector.Conn
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{
+		CreateClauses: []string{"INSERT", "VALUES", "ON CONFLICT"},
+		UpdateClauses: []string{"UPDATE", "SET", "WHERE"},
+		DeleteClauses: []string{"DELETE", "FROM", "WHERE"},
+	})
+	return nil
+}
+
+func (dialector Dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return nil
+}
+
+func (dialector Dialector) DataTypeOf(field *schema.Field) string {
+	switch field.DataType {
+	case schema.Bool:
+		return "boolean"
+	case schema.Int, schema.Uint:
+		return "bigint"
+	case schema.Float:
+		return "double precision"
+	case schema.Time:
+		return "timestamptz"
+	case schema.Bytes:
+		return "bytea"
+	case schema.String:
+		return "text"
+	}
+	return string(field.DataType)
+}
+
+func (dialector Dialector) DefaultValueOf(field *schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+
+func (dialector Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v any) {
+	if dialector.Dialect == "postgres" {
+		writer.WriteByte('$')
+		writer.WriteString(strconv.Itoa(len(stmt.Vars)))
+		return
+	}
+	writer.WriteByte('?')
+}
+
+func (dialector Dialector) QuoteTo(writer clause.Writer, str string) {
+	quote := "`"
+	if dialector.Dialect == "postgres" {
+		quote = `"`
+	}
+	parts := strings.Split(str, ".")
+	for i, part := range parts {
+		if i > 0 {
+			writer.WriteByte('.')
+		}
+		writer.WriteString(quote + strings.ReplaceAll(part, quote, quote+quote) + quote)
+	}
+}
+
+func (dialector Dialector) SavePoint(tx *gorm.DB, name string) error {
+	return tx.Exec("SAVEPOINT " + name).Error
+}
+
+func (dialector Dialector) RollbackTo(tx *gorm.DB, name string) error {
+	return tx.Exec("ROLLBACK TO SAVEPOINT " + name).Error
+}
+
+func (dialector Dialector) Explain(sql string, vars ...any) string {
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
+
+type sqlDriver struct{}
+
+func (sqlDriver) Open(dsn string) (driver.Conn, error) {
+	recorder, ok := recorders.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("dbtest: unknown recorder %s", dsn)
+	}
+	return &conn{recorder: recorder.(*Recorder)}, nil
+}
+
+type conn struct {
+	recorder *Recorder
+}
+
+func (conn *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: conn, query: query}, nil
+}
+
+func (conn *conn) Close() error {
+	return nil
+}
+
+func (conn *conn) Begin() (driver.Tx, error) {
+	return conn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (conn *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if _, err := conn.recorder.record("BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return tx{conn: conn}, nil
+}
+
+func (conn *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := conn.recorder.record(query, args); err != nil {
+		return nil, err
+	}
+	return result{}, nil
+}
+
+func (conn *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values, err := conn.recorder.record(query, args)
+	if err != nil {
+		return nil, err
+	}
+	result := Result{}
+	if conn.recorder.Query != nil {
+		result = conn.recorder.Query(query, values)
+	}
+	return &rows{result: result}, nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (stmt *stmt) Close() error {
+	return nil
+}
+
+func (stmt *stmt) NumInput() int {
+	return -1
+}
+
+func (stmt *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return stmt.conn.ExecContext(context.Background(), stmt.query, namedValues(args))
+}
+
+func (stmt *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return stmt.conn.QueryContext(context.Background(), stmt.query, namedValues(args))
+}
+
+type tx struct {
+	conn *conn
+}
+
+func (tx tx) Commit() error {
+	_, err := tx.conn.recorder.record("COMMIT", nil)
+	return err
+}
+
+func (tx tx) Rollback() error {
+	_, err := tx.conn.recorder.record("ROLLBACK", nil)
+	return err
+}
+
+type rows struct {
+	result Result
+	index  int
+}
+
+func (rows *rows) Columns() []string {
+	return rows.result.Columns
+}
+
+func (rows *rows) Close() error {
+	return nil
+}
+
+func (rows *rows) Next(dest []driver.Value) error {
+	if rows.index >= len(rows.result.Rows) {
+		return io.EOF
+	}
+	copy(dest, rows.result.Rows[rows.index])
+	rows.index++
+	return nil
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return values
+}
+
+type result struct{}
+
+func (result) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (result) RowsAffected() (int64, error) {
+	return 1, nil
+}
@@ -1,11 +1,14 @@
 package middleware
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/misakacoder/inuyasha/http/req"
 	"github.com/misakacoder/inuyasha/http/resp"
 	"github.com/misakacoder/inuyasha/model"
 	"github.com/misakacoder/inuyasha/pkg/jwt"
+	"net/http"
+	"time"
 )
 
 func Jwt(manager jwt.Manager) gin.HandlerFunc {
@@ -17,11 +20,20 @@ func Jwt(manager jwt.Manager) gin.HandlerFunc {
 		if token.Token == "" {
 			token = req.BindForm(ctx, token)
 		}
+		cookie := false
 		if token.Token == "" {
 			value, _ := ctx.Cookie("token")
 			token.Token = value
+			cookie = value != ""
+		}
+		if token.RefreshToken == "" {
+			value, _ := ctx.Cookie("refreshToken")
+			token.RefreshToken = value
 		}
 		claims, err := manager.Parse(token.Token)
+		if errors.Is(err, jwt.ErrTokenExpired) && token.RefreshToken != "" {
+			claims, err = renew(ctx, manager, token.RefreshToken, cookie)
+		}
 		if claims == nil || err != nil {
 			resp.NotLogin.Msg("token expired!").Write(ctx)
 			ctx.Abort()
@@ -31,3 +43,20 @@ func Jwt(manager jwt.Manager) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+func renew(ctx *gin.Context, manager jwt.Manager, refreshToken string, cookie bool) (*jwt.Claims, error) {
+	pair, err := manager.Refresh(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Header("token", pair.AccessToken)
+	ctx.Header("refresh-token", pair.RefreshToken)
+	ctx.Writer.Header().Add("Access-Control-Expose-Headers", "token, refresh-token")
+	if cookie {
+		secure := ctx.Request.TLS != nil
+		ctx.SetSameSite(http.SameSiteLaxMode)
+		ctx.SetCookie("token", pair.AccessToken, int(time.Until(pair.ExpiresAt.Time).Seconds()), "/", "", secure, true)
+		ctx.SetCookie("refreshToken", pair.RefreshToken, int(time.Until(pair.RefreshExpiresAt.Time).Seconds()), "/", "", secure, true)
+	}
+	return pair.Claims, nil
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/pkg/jwt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJwtRenew(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		request func(token string, refreshToken string) *http.Request
+		status  int
+	}{
+		{
+			name: "header",
+			request: func(token string, refreshToken string) *http.Request {
+				request := httptest.NewRequest(http.MethodGet, "/", nil)
+				request.Header.Set("token", token)
+				request.Header.Set("refresh-token", refreshToken)
+				return request
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "cookie",
+			request: func(token string, refreshToken string) *http.Request {
+				request := httptest.NewRequest(http.MethodGet, "/", nil)
+				request.AddCookie(&http.Cookie{Name: "token", Value: token})
+				request.AddCookie(&http.Cookie{Name: "refreshToken", Value: refreshToken})
+				return request
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "query",
+			request: func(token string, refreshToken string) *http.Request {
+				query := url.Values{"token": {token}, "refreshToken": {refreshToken}}
+				return httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "form",
+			request: func(token string, refreshToken string) *http.Request {
+				form := url.Values{"token": {token}, "refreshToken": {refreshToken}}
+				request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return request
+			},
+			status: http.StatusUnauthorized,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expired := jwt.NewManager("secret", -time.Minute)
+			pair, err := expired.CreatePair("user", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			engine := gin.New()
+			engine.Any("/", Jwt(jwt.NewManager("secret", time.Minute)), func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, test.request(pair.AccessToken, pair.RefreshToken))
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, test.status)
+			}
+			if renewed := recorder.Header().Get("token") != ""; renewed != (test.status == http.StatusOK) {
+				t.Fatalf("renewed = %v", renewed)
+			}
+		})
+	}
+}
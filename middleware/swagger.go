@@ -4,16 +4,33 @@ import (
 	"embed"
 	"github.com/gin-gonic/gin"
 	"github.com/misakacoder/inuyasha/configs"
+	"github.com/misakacoder/inuyasha/pkg/enum"
+	"net/http"
+	"strings"
 )
 
+// Swagger serves the embedded swagger files. Swagger documents (*swagger.json)
+// are served with the registered enums merged into their definitions.
 func Swagger(embedFS embed.FS) gin.HandlerFunc {
 	handler := AuthFS(NewEmbedFS(embedFS), swaggerAuth)
 	return func(ctx *gin.Context) {
-		if configs.Config.Swagger.Enabled {
-			handler(ctx)
-		} else {
+		if !configs.Config.Swagger.Enabled {
 			ctx.Next()
+			return
 		}
+		path := strings.TrimPrefix(ctx.Request.URL.Path, "/")
+		if strings.HasSuffix(path, "swagger.json") {
+			if document, err := embedFS.ReadFile(path); err == nil {
+				if swaggerAuth(ctx) {
+					document, err = enum.MergeDefinitions(document)
+					Panic(err)
+					ctx.Data(http.StatusOK, "application/json; charset=utf-8", document)
+				}
+				ctx.Abort()
+				return
+			}
+		}
+		handler(ctx)
 	}
 }
 
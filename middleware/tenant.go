@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/http/resp"
+	"github.com/misakacoder/inuyasha/pkg/jwt"
+	"github.com/misakacoder/inuyasha/pkg/tenant"
+	"net"
+	"slices"
+	"strings"
+)
+
+type TenantResolver func(ctx *gin.Context) string
+
+// Tenant accepts a tenant only when the authenticated claims grant it. The tenant
+// listed under claimKey (a string or a list) is used directly; resolvers such as
+// HeaderTenant or SubdomainTenant may only choose among the granted tenants.
+func Tenant(claimKey string, resolvers ...TenantResolver) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		granted := claimTenants(ctx, claimKey)
+		tenantID := ""
+		for _, resolver := range resolvers {
+			if tenantID = strings.TrimSpace(resolver(ctx)); tenantID != "" {
+				break
+			}
+		}
+		if tenantID == "" && len(granted) == 1 {
+			tenantID = granted[0]
+		}
+		if tenantID == "" || !slices.Contains(granted, tenantID) {
+			resp.AccessDenied.Msg("tenant not found!").Write(ctx)
+			ctx.Abort()
+			return
+		}
+		ctx.Set("tenant", tenantID)
+		ctx.Request = ctx.Request.WithContext(tenant.WithTenant(ctx.Request.Context(), tenantID))
+		ctx.Next()
+	}
+}
+
+func HeaderTenant(name string) TenantResolver {
+	return func(ctx *gin.Context) string {
+		return ctx.GetHeader(name)
+	}
+}
+
+func ClaimTenant(key string) TenantResolver {
+	return func(ctx *gin.Context) string {
+		if tenants := claimTenants(ctx, key); len(tenants) == 1 {
+			return tenants[0]
+		}
+		return ""
+	}
+}
+
+func SubdomainTenant(domain string) TenantResolver {
+	suffix := "." + strings.TrimPrefix(domain, ".")
+	return func(ctx *gin.Context) string {
+		host := ctx.Request.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		subdomain := strings.TrimSuffix(host, suffix)
+		if strings.Contains(subdomain, ".") {
+			return ""
+		}
+		return subdomain
+	}
+}
+
+func claimTenants(ctx *gin.Context, key string) []string {
+	value, ok := ctx.Get("claims")
+	if !ok {
+		return nil
+	}
+	claims, ok := value.(*jwt.Claims)
+	if !ok || claims.Payload == nil {
+		return nil
+	}
+	switch tenants := claims.Payload[key].(type) {
+	case nil:
+		return nil
+	case []string:
+		return tenants
+	case []any:
+		result := make([]string, 0, len(tenants))
+		for _, tenantID := range tenants {
+			result = append(result, fmt.Sprintf("%v", tenantID))
+		}
+		return result
+	default:
+		return []string{fmt.Sprintf("%v", tenants)}
+	}
+}
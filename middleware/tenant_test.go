@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/pkg/jwt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		payload map[string]any
+		header  string
+		status  int
+		tenant  string
+	}{
+		{"single claim", map[string]any{"tenantId": "t1"}, "", http.StatusOK, "t1"},
+		{"header within grant", map[string]any{"tenantId": []any{"t1", "t2"}}, "t2", http.StatusOK, "t2"},
+		{"header outside grant", map[string]any{"tenantId": "t1"}, "t2", http.StatusForbidden, ""},
+		{"ambiguous grant", map[string]any{"tenantId": []any{"t1", "t2"}}, "", http.StatusForbidden, ""},
+		{"no claims", nil, "t1", http.StatusForbidden, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := gin.New()
+			tenantID := ""
+			engine.GET("/", func(ctx *gin.Context) {
+				if test.payload != nil {
+					ctx.Set("claims", &jwt.Claims{Payload: test.payload})
+				}
+			}, Tenant("tenantId", HeaderTenant("X-Tenant")), func(ctx *gin.Context) {
+				tenantID = ctx.GetString("tenant")
+			})
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				request.Header.Set("X-Tenant", test.header)
+			}
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, request)
+			if recorder.Code != test.status || tenantID != test.tenant {
+				t.Fatalf("status = %d tenant = %q, want %d %q", recorder.Code, tenantID, test.status, test.tenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package model
+
+type SlowQuery struct {
+	//排序方式 duration：按耗时倒序 time：按时间倒序
+	Sort string `form:"sort" binding:"trim" enums:"duration,time"`
+	//分组方式 fingerprint：按SQL指纹分组
+	Group string `form:"group" binding:"trim" enums:"fingerprint"`
+}
@@ -1,5 +1,6 @@
 package model
 
 type Token struct {
-	Token string `form:"token" header:"token"`
+	Token        string `form:"token" header:"token"`
+	RefreshToken string `form:"-" header:"refresh-token"`
 }
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrClosed = errors.New("cache is closed")
+
+type Cache interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+}
+
+func Get[T any](cache Cache, codec Codec, key string) (T, bool, error) {
+	var result T
+	data, ok, err := cache.Get(key)
+	if err != nil || !ok {
+		return result, false, err
+	}
+	if err = codec.Unmarshal(data, &result); err != nil {
+		return result, false, err
+	}
+	return result, true, nil
+}
+
+func Set[T any](cache Cache, codec Codec, key string, value T, ttl time.Duration) error {
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return cache.Set(key, data, ttl)
+}
+
+func GetJSON[T any](cache Cache, key string) (T, bool, error) {
+	return Get[T](cache, JSONCodec, key)
+}
+
+func SetJSON[T any](cache Cache, key string, value T, ttl time.Duration) error {
+	return Set(cache, JSONCodec, key, value, ttl)
+}
@@ -0,0 +1,38 @@
+package cache
+
+import "sync"
+
+type Group struct {
+	mutex sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	wg    sync.WaitGroup
+	value any
+	err   error
+}
+
+func (group *Group) Do(key string, fn func() (any, error)) (any, error) {
+	group.mutex.Lock()
+	if group.calls == nil {
+		group.calls = map[string]*call{}
+	}
+	if c, ok := group.calls[key]; ok {
+		group.mutex.Unlock()
+		c.wg.Wait()
+		return c.value, c.err
+	}
+	c := &call{}
+	c.wg.Add(1)
+	group.calls[key] = c
+	group.mutex.Unlock()
+	defer func() {
+		group.mutex.Lock()
+		delete(group.calls, key)
+		group.mutex.Unlock()
+		c.wg.Done()
+	}()
+	c.value, c.err = fn()
+	return c.value, c.err
+}
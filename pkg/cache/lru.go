@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const defaultCapacity = 1024
+
+type LRU struct {
+	mutex    sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	list     *list.List
+}
+
+type entry struct {
+	key      string
+	value    []byte
+	expireAt time.Time
+}
+
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		capacity = defaultCapacity
+	}
+	return &LRU{
+		capacity: capacity,
+		items:    make(map[string]*list.Element, capacity),
+		list:     list.New(),
+	}
+}
+
+func (lru *LRU) Get(key string) ([]byte, bool, error) {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	element, ok := lru.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	item := element.Value.(*entry)
+	if !item.expireAt.IsZero() && time.Now().After(item.expireAt) {
+		lru.remove(element)
+		return nil, false, nil
+	}
+	lru.list.MoveToFront(element)
+	return item.value, true, nil
+}
+
+func (lru *LRU) Set(key string, value []byte, ttl time.Duration) error {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+	if element, ok := lru.items[key]; ok {
+		item := element.Value.(*entry)
+		item.value = value
+		item.expireAt = expireAt
+		lru.list.MoveToFront(element)
+		return nil
+	}
+	lru.items[key] = lru.list.PushFront(&entry{key: key, value: value, expireAt: expireAt})
+	for lru.list.Len() > lru.capacity {
+		lru.remove(lru.list.Back())
+	}
+	return nil
+}
+
+func (lru *LRU) Delete(keys ...string) error {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	for _, key := range keys {
+		if element, ok := lru.items[key]; ok {
+			lru.remove(element)
+		}
+	}
+	return nil
+}
+
+func (lru *LRU) Len() int {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	return lru.list.Len()
+}
+
+func (lru *LRU) remove(element *list.Element) {
+	lru.list.Remove(element)
+	delete(lru.items, element.Value.(*entry).key)
+}
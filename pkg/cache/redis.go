@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRedisPoolSize = 8
+	defaultRedisTimeout  = 3 * time.Second
+)
+
+type RedisConfig struct {
+	Addr     string        `yaml:"addr"`
+	Password string        `yaml:"password"`
+	DB       int           `yaml:"db"`
+	PoolSize int           `yaml:"poolSize"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Prefix   string        `yaml:"prefix"`
+}
+
+type Redis struct {
+	config RedisConfig
+	pool   chan *redisConn
+	mutex  sync.Mutex
+	closed bool
+}
+
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+type redisError string
+
+func (err redisError) Error() string {
+	return string(err)
+}
+
+func NewRedis(config RedisConfig) *Redis {
+	if config.PoolSize <= 0 {
+		config.PoolSize = defaultRedisPoolSize
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultRedisTimeout
+	}
+	return &Redis{
+		config: config,
+		pool:   make(chan *redisConn, config.PoolSize),
+	}
+}
+
+func (redis *Redis) Get(key string) ([]byte, bool, error) {
+	reply, err := redis.do("GET", redis.config.Prefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected redis reply %T", reply)
+	}
+	return data, true, nil
+}
+
+func (redis *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", redis.config.Prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := redis.do(args...)
+	return err
+}
+
+func (redis *Redis) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := []string{"DEL"}
+	for _, key := range keys {
+		args = append(args, redis.config.Prefix+key)
+	}
+	_, err := redis.do(args...)
+	return err
+}
+
+func (redis *Redis) Close() error {
+	redis.mutex.Lock()
+	defer redis.mutex.Unlock()
+	if !redis.closed {
+		redis.closed = true
+		close(redis.pool)
+		for conn := range redis.pool {
+			conn.Close()
+		}
+	}
+	return nil
+}
+
+func (redis *Redis) do(args ...string) (any, error) {
+	conn, err := redis.conn()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(redis.config.Timeout, args...)
+	var replyError redisError
+	if err != nil && !errors.As(err, &replyError) {
+		conn.Close()
+		return nil, err
+	}
+	redis.release(conn)
+	return reply, err
+}
+
+func (redis *Redis) conn() (*redisConn, error) {
+	select {
+	case conn, ok := <-redis.pool:
+		if !ok {
+			return nil, ErrClosed
+		}
+		return conn, nil
+	default:
+	}
+	config := redis.config
+	netConn, err := net.DialTimeout("tcp", config.Addr, config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+	if config.Password != "" {
+		if _, err = conn.do(config.Timeout, "AUTH", config.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if config.DB > 0 {
+		if _, err = conn.do(config.Timeout, "SELECT", strconv.Itoa(config.DB)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (redis *Redis) release(conn *redisConn) {
+	redis.mutex.Lock()
+	defer redis.mutex.Unlock()
+	if redis.closed {
+		conn.Close()
+		return
+	}
+	select {
+	case redis.pool <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+func (conn *redisConn) do(timeout time.Duration, args ...string) (any, error) {
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	writer := bufio.NewWriter(conn)
+	fmt.Fprintf(writer, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	return conn.read()
+}
+
+func (conn *redisConn) read() (any, error) {
+	line, err := conn.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, fmt.Errorf("invalid redis reply %q", line)
+	}
+	content := line[1 : len(line)-2]
+	switch line[0] {
+	case '+':
+		return content, nil
+	case '-':
+		return nil, redisError(content)
+	case ':':
+		return strconv.ParseInt(content, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(content)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(conn.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		size, err := strconv.Atoi(content)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		values := make([]any, size)
+		for i := range values {
+			if values[i], err = conn.read(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("invalid redis reply %q", line)
+}
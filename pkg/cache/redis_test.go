@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRedisRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  any
+		err   string
+	}{
+		{"simple string", "+OK\r\n", "OK", ""},
+		{"error", "-ERR wrong type\r\n", nil, "ERR wrong type"},
+		{"integer", ":42\r\n", int64(42), ""},
+		{"bulk", "$5\r\nhello\r\n", []byte("hello"), ""},
+		{"empty bulk", "$0\r\n\r\n", []byte{}, ""},
+		{"binary bulk", "$4\r\na\r\nb\r\n", []byte("a\r\nb"), ""},
+		{"nil bulk", "$-1\r\n", nil, ""},
+		{"array", "*3\r\n$1\r\na\r\n:1\r\n$-1\r\n", []any{[]byte("a"), int64(1), nil}, ""},
+		{"nested array", "*1\r\n*1\r\n+x\r\n", []any{[]any{"x"}}, ""},
+		{"nil array", "*-1\r\n", nil, ""},
+		{"invalid type", "?x\r\n", nil, "invalid redis reply"},
+		{"invalid length", "$x\r\n", nil, "invalid syntax"},
+		{"truncated bulk", "$5\r\nhe", nil, "EOF"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := &redisConn{reader: bufio.NewReader(strings.NewReader(test.input))}
+			got, err := conn.read()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("err = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRedisCache(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	redis := NewRedis(RedisConfig{Addr: server.addr, Password: "secret", DB: 2, Prefix: "app:"})
+	defer redis.Close()
+
+	if _, ok, err := redis.Get("missing"); ok || err != nil {
+		t.Fatalf("missing key: ok=%v err=%v", ok, err)
+	}
+	if err := redis.Set("key", []byte("va\r\nlue"), 0); err != nil {
+		t.Fatal(err)
+	}
+	data, ok, err := redis.Get("key")
+	if err != nil || !ok || string(data) != "va\r\nlue" {
+		t.Fatalf("get: %q %v %v", data, ok, err)
+	}
+	if server.value("app:key") == "" {
+		t.Fatal("prefix not applied")
+	}
+	if err = redis.Set("ttl", []byte("x"), 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, ok, _ = redis.Get("ttl"); ok {
+		t.Fatal("ttl not applied")
+	}
+	if err = redis.Delete("key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ = redis.Get("key"); ok {
+		t.Fatal("delete failed")
+	}
+	if err = SetJSON(redis, "json", map[string]int{"a": 1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	value, ok, err := GetJSON[map[string]int](redis, "json")
+	if err != nil || !ok || value["a"] != 1 {
+		t.Fatalf("json: %v %v %v", value, ok, err)
+	}
+	var replyError redisError
+	if _, err = redis.do("BOGUS"); !errors.As(err, &replyError) {
+		t.Fatalf("expected reply error, got %v", err)
+	}
+	if _, _, err = redis.Get("key"); err != nil {
+		t.Fatalf("connection not reusable after reply error: %v", err)
+	}
+}
+
+func TestRedisAuthFailure(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	redis := NewRedis(RedisConfig{Addr: server.addr, Password: "wrong"})
+	defer redis.Close()
+	if _, _, err := redis.Get("key"); err == nil {
+		t.Fatal("expected auth error")
+	}
+}
+
+func TestRedisClosed(t *testing.T) {
+	server := newFakeRedis(t, "")
+	redis := NewRedis(RedisConfig{Addr: server.addr})
+	redis.Close()
+	if _, _, err := redis.Get("key"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+type fakeRedis struct {
+	addr     string
+	password string
+	mutex    sync.Mutex
+	data     map[string]fakeEntry
+}
+
+type fakeEntry struct {
+	value    string
+	expireAt time.Time
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	server := &fakeRedis{addr: listener.Addr().String(), password: password, data: map[string]fakeEntry{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (server *fakeRedis) value(key string) string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.data[key].value
+}
+
+func (server *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed := server.password == ""
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(args[0])
+		if command != "AUTH" && !authed {
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		switch command {
+		case "AUTH":
+			if args[1] != server.password {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authed = true
+			fmt.Fprint(conn, "+OK\r\n")
+		case "SELECT":
+			fmt.Fprint(conn, "+OK\r\n")
+		case "GET":
+			server.mutex.Lock()
+			entry, ok := server.data[args[1]]
+			if ok && !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+				delete(server.data, args[1])
+				ok = false
+			}
+			server.mutex.Unlock()
+			if !ok {
+				fmt.Fprint(conn, "$-1\r\n")
+				continue
+			}
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(entry.value), entry.value)
+		case "SET":
+			entry := fakeEntry{value: args[2]}
+			if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+				ms, _ := strconv.Atoi(args[4])
+				entry.expireAt = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			}
+			server.mutex.Lock()
+			server.data[args[1]] = entry
+			server.mutex.Unlock()
+			fmt.Fprint(conn, "+OK\r\n")
+		case "DEL":
+			server.mutex.Lock()
+			count := 0
+			for _, key := range args[1:] {
+				if _, ok := server.data[key]; ok {
+					delete(server.data, key)
+					count++
+				}
+			}
+			server.mutex.Unlock()
+			fmt.Fprintf(conn, ":%d\r\n", count)
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
@@ -0,0 +1,258 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const startTimeKey = "metrics:start"
+
+var (
+	Buckets    = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	mutex      sync.RWMutex
+	collectors []*collector
+)
+
+type Stats struct {
+	Name    string       `json:"name"`
+	Pool    PoolStats    `json:"pool"`
+	Queries []QueryStats `json:"queries"`
+}
+
+type PoolStats struct {
+	MaxOpenConnections int     `json:"maxOpenConnections"`
+	OpenConnections    int     `json:"openConnections"`
+	InUse              int     `json:"inUse"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"waitCount"`
+	WaitDuration       float64 `json:"waitDuration"`
+	MaxIdleClosed      int64   `json:"maxIdleClosed"`
+	MaxIdleTimeClosed  int64   `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed  int64   `json:"maxLifetimeClosed"`
+}
+
+type QueryStats struct {
+	Table     string            `json:"table"`
+	Operation string            `json:"operation"`
+	Count     uint64            `json:"count"`
+	Errors    uint64            `json:"errors"`
+	Total     float64           `json:"total"`
+	Avg       float64           `json:"avg"`
+	Max       float64           `json:"max"`
+	Buckets   map[string]uint64 `json:"buckets"`
+}
+
+type collector struct {
+	name    string
+	db      *gorm.DB
+	mutex   sync.Mutex
+	queries map[queryKey]*histogram
+}
+
+type queryKey struct {
+	table     string
+	operation string
+}
+
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	errors  uint64
+	sum     time.Duration
+	max     time.Duration
+}
+
+func Register(name string, db *gorm.DB) error {
+	c := &collector{name: name, db: db, queries: map[queryKey]*histogram{}}
+	callback := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callback.Create().Before("gorm:create").Register, callback.Create().After("gorm:create").Register},
+		{"query", callback.Query().Before("gorm:query").Register, callback.Query().After("gorm:query").Register},
+		{"update", callback.Update().Before("gorm:update").Register, callback.Update().After("gorm:update").Register},
+		{"delete", callback.Delete().Before("gorm:delete").Register, callback.Delete().After("gorm:delete").Register},
+		{"row", callback.Row().Before("gorm:row").Register, callback.Row().After("gorm:row").Register},
+		{"raw", callback.Raw().Before("gorm:raw").Register, callback.Raw().After("gorm:raw").Register},
+	}
+	for _, processor := range processors {
+		if err := processor.before("metrics:before_"+processor.operation, before); err != nil {
+			return err
+		}
+		if err := processor.after("metrics:after_"+processor.operation, c.after(processor.operation)); err != nil {
+			return err
+		}
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	collectors = append(collectors, c)
+	return nil
+}
+
+func Snapshot() []Stats {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	result := make([]Stats, 0, len(collectors))
+	for _, c := range collectors {
+		result = append(result, c.snapshot())
+	}
+	return result
+}
+
+func WritePrometheus(writer io.Writer) error {
+	stats := Snapshot()
+	builder := &strings.Builder{}
+	gauges := []struct {
+		name  string
+		help  string
+		value func(pool PoolStats) string
+	}{
+		{"inuyasha_db_max_open_connections", "Maximum number of open connections to the database.", func(pool PoolStats) string { return strconv.Itoa(pool.MaxOpenConnections) }},
+		{"inuyasha_db_open_connections", "The number of established connections both in use and idle.", func(pool PoolStats) string { return strconv.Itoa(pool.OpenConnections) }},
+		{"inuyasha_db_in_use_connections", "The number of connections currently in use.", func(pool PoolStats) string { return strconv.Itoa(pool.InUse) }},
+		{"inuyasha_db_idle_connections", "The number of idle connections.", func(pool PoolStats) string { return strconv.Itoa(pool.Idle) }},
+		{"inuyasha_db_wait_count_total", "The total number of connections waited for.", func(pool PoolStats) string { return strconv.FormatInt(pool.WaitCount, 10) }},
+		{"inuyasha_db_wait_duration_seconds_total", "The total time blocked waiting for a new connection.", func(pool PoolStats) string { return formatFloat(pool.WaitDuration / 1000) }},
+		{"inuyasha_db_max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns.", func(pool PoolStats) string { return strconv.FormatInt(pool.MaxIdleClosed, 10) }},
+		{"inuyasha_db_max_idle_time_closed_total", "The total number of connections closed due to SetConnMaxIdleTime.", func(pool PoolStats) string { return strconv.FormatInt(pool.MaxIdleTimeClosed, 10) }},
+		{"inuyasha_db_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.", func(pool PoolStats) string { return strconv.FormatInt(pool.MaxLifetimeClosed, 10) }},
+	}
+	for _, gauge := range gauges {
+		metricType := "gauge"
+		if strings.HasSuffix(gauge.name, "_total") {
+			metricType = "counter"
+		}
+		fmt.Fprintf(builder, "# HELP %s %s\n# TYPE %s %s\n", gauge.name, gauge.help, gauge.name, metricType)
+		for _, stat := range stats {
+			fmt.Fprintf(builder, "%s{db=%q} %s\n", gauge.name, stat.Name, gauge.value(stat.Pool))
+		}
+	}
+	builder.WriteString("# HELP inuyasha_db_query_duration_seconds The duration of database operations.\n")
+	builder.WriteString("# TYPE inuyasha_db_query_duration_seconds histogram\n")
+	for _, stat := range stats {
+		for _, query := range stat.Queries {
+			labels := fmt.Sprintf("db=%q,table=%q,operation=%q", stat.Name, query.Table, query.Operation)
+			for _, bucket := range Buckets {
+				le := formatFloat(bucket)
+				fmt.Fprintf(builder, "inuyasha_db_query_duration_seconds_bucket{%s,le=%q} %d\n", labels, le, query.Buckets[le])
+			}
+			fmt.Fprintf(builder, "inuyasha_db_query_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, query.Count)
+			fmt.Fprintf(builder, "inuyasha_db_query_duration_seconds_sum{%s} %s\n", labels, formatFloat(query.Total/1000))
+			fmt.Fprintf(builder, "inuyasha_db_query_duration_seconds_count{%s} %d\n", labels, query.Count)
+		}
+	}
+	builder.WriteString("# HELP inuyasha_db_query_errors_total The total number of failed database operations.\n")
+	builder.WriteString("# TYPE inuyasha_db_query_errors_total counter\n")
+	for _, stat := range stats {
+		for _, query := range stat.Queries {
+			fmt.Fprintf(builder, "inuyasha_db_query_errors_total{db=%q,table=%q,operation=%q} %d\n", stat.Name, query.Table, query.Operation, query.Errors)
+		}
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func (c *collector) after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(start)
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		key := queryKey{table: table, operation: operation}
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		h, ok := c.queries[key]
+		if !ok {
+			h = &histogram{buckets: make([]uint64, len(Buckets))}
+			c.queries[key] = h
+		}
+		seconds := elapsed.Seconds()
+		for i, bucket := range Buckets {
+			if seconds <= bucket {
+				h.buckets[i]++
+			}
+		}
+		h.count++
+		h.sum += elapsed
+		h.max = max(h.max, elapsed)
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			h.errors++
+		}
+	}
+}
+
+func (c *collector) snapshot() Stats {
+	stats := Stats{Name: c.name, Queries: []QueryStats{}}
+	if sqlDB, err := c.db.DB(); err == nil {
+		dbStats := sqlDB.Stats()
+		stats.Pool = PoolStats{
+			MaxOpenConnections: dbStats.MaxOpenConnections,
+			OpenConnections:    dbStats.OpenConnections,
+			InUse:              dbStats.InUse,
+			Idle:               dbStats.Idle,
+			WaitCount:          dbStats.WaitCount,
+			WaitDuration:       milliseconds(dbStats.WaitDuration),
+			MaxIdleClosed:      dbStats.MaxIdleClosed,
+			MaxIdleTimeClosed:  dbStats.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  dbStats.MaxLifetimeClosed,
+		}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, h := range c.queries {
+		query := QueryStats{
+			Table:     key.table,
+			Operation: key.operation,
+			Count:     h.count,
+			Errors:    h.errors,
+			Total:     milliseconds(h.sum),
+			Max:       milliseconds(h.max),
+			Buckets:   make(map[string]uint64, len(Buckets)),
+		}
+		if h.count > 0 {
+			query.Avg = query.Total / float64(h.count)
+		}
+		for i, bucket := range Buckets {
+			query.Buckets[formatFloat(bucket)] = h.buckets[i]
+		}
+		stats.Queries = append(stats.Queries, query)
+	}
+	sort.Slice(stats.Queries, func(i, j int) bool {
+		a, b := stats.Queries[i], stats.Queries[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		return a.Operation < b.Operation
+	})
+	return stats
+}
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
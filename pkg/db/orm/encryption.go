@@ -0,0 +1,232 @@
+package orm
+
+import (
+	"fmt"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const blindIndexTag = "blindIndex"
+
+var blindIndexCache sync.Map
+
+func (orm *Gorm) EnableEncryption() error {
+	return RegisterEncryption(orm.DB)
+}
+
+func RegisterEncryption(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register("encryption:create", blindIndexAssign); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("encryption:query", blindIndexWhere); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("encryption:update_where", blindIndexWhere); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("encryption:update", blindIndexAssign); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("encryption:delete", blindIndexWhere); err != nil {
+		return err
+	}
+	return callback.Row().Before("gorm:row").Register("encryption:row", blindIndexWhere)
+}
+
+func blindIndexAssign(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	fields := blindIndexFields(stmt.Schema)
+	if len(fields) == 0 {
+		return
+	}
+	ctx := stmtContext(stmt)
+	assign := func(value reflect.Value) {
+		for field, companion := range fields {
+			fieldValue, zero := field.ValueOf(ctx, value)
+			indexer, ok := fieldValue.(types.BlindIndexer)
+			if zero || !ok {
+				continue
+			}
+			index, err := indexer.BlindIndex()
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			if err = companion.Set(ctx, value, index); err != nil {
+				db.AddError(err)
+				return
+			}
+			selectCompanion(stmt, field, companion)
+		}
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			assign(reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		assign(stmt.ReflectValue)
+	}
+	if dest := reflect.Indirect(reflect.ValueOf(stmt.Dest)); dest.Kind() == reflect.Struct && dest.Type() == stmt.Schema.ModelType && dest.CanAddr() {
+		if stmt.ReflectValue.Kind() != reflect.Struct || dest.Addr().Pointer() != stmt.ReflectValue.Addr().Pointer() {
+			assign(dest)
+		}
+	}
+}
+
+func blindIndexWhere(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	whereClause, ok := stmt.Clauses["WHERE"]
+	if !ok {
+		return
+	}
+	where, ok := whereClause.Expression.(clause.Where)
+	if !ok {
+		return
+	}
+	exprs, err := rewriteBlindIndex(stmt.Schema, where.Exprs)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	whereClause.Expression = clause.Where{Exprs: exprs}
+	stmt.Clauses["WHERE"] = whereClause
+}
+
+func rewriteBlindIndex(sch *schema.Schema, exprs []clause.Expression) ([]clause.Expression, error) {
+	result := make([]clause.Expression, len(exprs))
+	for i, expr := range exprs {
+		var err error
+		switch e := expr.(type) {
+		case clause.Eq:
+			expr, err = rewriteEq(sch, e)
+		case clause.IN:
+			expr, err = rewriteIN(sch, e)
+		case clause.AndConditions:
+			e.Exprs, err = rewriteBlindIndex(sch, e.Exprs)
+			expr = e
+		case clause.OrConditions:
+			e.Exprs, err = rewriteBlindIndex(sch, e.Exprs)
+			expr = e
+		case clause.NotConditions:
+			e.Exprs, err = rewriteBlindIndex(sch, e.Exprs)
+			expr = e
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[i] = expr
+	}
+	return result, nil
+}
+
+func rewriteEq(sch *schema.Schema, eq clause.Eq) (clause.Expression, error) {
+	indexer, ok := eq.Value.(types.BlindIndexer)
+	if !ok {
+		return eq, nil
+	}
+	column, err := blindIndexColumn(sch, eq.Column)
+	if err != nil || column == nil {
+		return eq, err
+	}
+	index, err := indexer.BlindIndex()
+	if err != nil {
+		return nil, err
+	}
+	return clause.Eq{Column: *column, Value: index}, nil
+}
+
+func rewriteIN(sch *schema.Schema, in clause.IN) (clause.Expression, error) {
+	if len(in.Values) == 0 {
+		return in, nil
+	}
+	if _, ok := in.Values[0].(types.BlindIndexer); !ok {
+		return in, nil
+	}
+	column, err := blindIndexColumn(sch, in.Column)
+	if err != nil || column == nil {
+		return in, err
+	}
+	values := make([]any, len(in.Values))
+	for i, value := range in.Values {
+		indexer, ok := value.(types.BlindIndexer)
+		if !ok {
+			return in, nil
+		}
+		if values[i], err = indexer.BlindIndex(); err != nil {
+			return nil, err
+		}
+	}
+	return clause.IN{Column: *column, Values: values}, nil
+}
+
+func blindIndexColumn(sch *schema.Schema, column any) (*clause.Column, error) {
+	var name, table string
+	switch c := column.(type) {
+	case clause.Column:
+		name, table = c.Name, c.Table
+	case string:
+		name = c
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			table, name = strings.Trim(name[:i], "`\"[]"), name[i+1:]
+		}
+		name = strings.Trim(name, "`\"[]")
+	default:
+		return nil, nil
+	}
+	field := sch.LookUpField(name)
+	if field == nil {
+		return nil, nil
+	}
+	companion := blindIndexFields(sch)[field]
+	if companion == nil {
+		return nil, fmt.Errorf("%s.%s is encrypted and has no %s tag", sch.Name, field.Name, blindIndexTag)
+	}
+	return &clause.Column{Table: table, Name: companion.DBName}, nil
+}
+
+func blindIndexFields(sch *schema.Schema) map[*schema.Field]*schema.Field {
+	if fields, ok := blindIndexCache.Load(sch); ok {
+		return fields.(map[*schema.Field]*schema.Field)
+	}
+	fields := map[*schema.Field]*schema.Field{}
+	for _, field := range sch.Fields {
+		if name := field.Tag.Get(blindIndexTag); name != "" {
+			if companion := sch.LookUpField(name); companion != nil {
+				fields[field] = companion
+			}
+		}
+	}
+	blindIndexCache.Store(sch, fields)
+	return fields
+}
+
+func selectCompanion(stmt *gorm.Statement, field *schema.Field, companion *schema.Field) {
+	if len(stmt.Selects) == 0 {
+		return
+	}
+	selected := false
+	for _, name := range stmt.Selects {
+		if name == companion.Name || name == companion.DBName {
+			return
+		}
+		if name == field.Name || name == field.DBName {
+			selected = true
+		}
+	}
+	if selected {
+		stmt.Selects = append(stmt.Selects, companion.DBName)
+	}
+}
@@ -0,0 +1,129 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var tablePattern = regexp.MustCompile("(?i)\\b(?:from|join|into|update|table)\\s+((?:[`\"\\[]?\\w+[`\"\\]]?\\.)?[`\"\\[]?\\w+[`\"\\]]?)")
+
+type SQLEntry struct {
+	Message string
+	SQL     string
+	Rows    int64
+	Elapsed time.Duration
+	Caller  string
+	Error   error
+	Tables  []string
+	Slow    bool
+}
+
+func (entry SQLEntry) Format() (string, []any) {
+	if entry.SQL == "" {
+		return "%s", []any{entry.Message}
+	}
+	rows := any(entry.Rows)
+	if entry.Rows < 0 {
+		rows = "-"
+	}
+	elapsed := float64(entry.Elapsed.Nanoseconds()) / 1e6
+	switch {
+	case entry.Error != nil:
+		return "%s [%.3fms] [rows:%v] %s", []any{entry.Error.Error(), elapsed, rows, entry.SQL}
+	case entry.Slow:
+		return "SLOW SQL [%.3fms] [rows:%v] %s", []any{elapsed, rows, entry.SQL}
+	default:
+		return "[%.3fms] [rows:%v] %s", []any{elapsed, rows, entry.SQL}
+	}
+}
+
+type sqlLogger struct {
+	orm           *Gorm
+	level         logger.LogLevel
+	slowThreshold time.Duration
+}
+
+func (sqlLogger *sqlLogger) LogMode(level logger.LogLevel) logger.Interface {
+	newLogger := *sqlLogger
+	newLogger.level = level
+	return &newLogger
+}
+
+func (sqlLogger *sqlLogger) Info(ctx context.Context, message string, args ...any) {
+	if sqlLogger.level >= logger.Info {
+		sqlLogger.log("INFO", SQLEntry{Message: fmt.Sprintf(message, args...), Caller: utils.FileWithLineNum()})
+	}
+}
+
+func (sqlLogger *sqlLogger) Warn(ctx context.Context, message string, args ...any) {
+	if sqlLogger.level >= logger.Warn {
+		sqlLogger.log("WARN", SQLEntry{Message: fmt.Sprintf(message, args...), Caller: utils.FileWithLineNum()})
+	}
+}
+
+func (sqlLogger *sqlLogger) Error(ctx context.Context, message string, args ...any) {
+	if sqlLogger.level >= logger.Error {
+		sqlLogger.log("ERROR", SQLEntry{Message: fmt.Sprintf(message, args...), Caller: utils.FileWithLineNum()})
+	}
+}
+
+func (sqlLogger *sqlLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if sqlLogger.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	notFound := errors.Is(err, gorm.ErrRecordNotFound)
+	slow := sqlLogger.slowThreshold > 0 && elapsed > sqlLogger.slowThreshold
+	level := "DEBUG"
+	switch {
+	case err != nil && !notFound && sqlLogger.level >= logger.Error:
+		level = "ERROR"
+	case slow && sqlLogger.level >= logger.Warn:
+		level = "WARN"
+	case sqlLogger.level < logger.Info:
+		return
+	}
+	sql, rows := fc()
+	entry := SQLEntry{
+		SQL:     sql,
+		Rows:    rows,
+		Elapsed: elapsed,
+		Caller:  utils.FileWithLineNum(),
+		Tables:  ParseTables(sql),
+		Slow:    slow,
+	}
+	if err != nil && !notFound {
+		entry.Error = err
+	}
+	sqlLogger.log(level, entry)
+}
+
+func (sqlLogger *sqlLogger) log(level string, entry SQLEntry) {
+	if fn := sqlLogger.orm.Logger; fn != nil {
+		fn(level, entry)
+	}
+}
+
+func ParseTables(sql string) []string {
+	var tables []string
+	seen := map[string]bool{}
+	for _, match := range tablePattern.FindAllStringSubmatch(sql, -1) {
+		name := match[1]
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		name = strings.Trim(name, "`\"[]")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			tables = append(tables, name)
+		}
+	}
+	return tables
+}
@@ -1,8 +1,12 @@
 package orm
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"github.com/misakacoder/inuyasha/pkg/db/metrics"
+	"github.com/misakacoder/inuyasha/pkg/db/slowlog"
+	"github.com/misakacoder/inuyasha/pkg/db/transaction"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
 	"github.com/misakacoder/kagome/cond"
 	"github.com/misakacoder/kagome/errs"
 	"github.com/misakacoder/kagome/str"
@@ -10,7 +14,6 @@ import (
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
 	"reflect"
-	"strings"
 	"time"
 )
 
@@ -29,62 +32,49 @@ type Tabler interface {
 }
 
 type Config struct {
-	DSN             string        `json:"dsn"`
-	MaxIdleConn     int           `yaml:"maxIdleConn"`
-	MaxOpenConn     int           `yaml:"maxOpenConn"`
-	ConnMaxLifeTime time.Duration `yaml:"connMaxLifeTime"`
-	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime"`
-	SlowSqlTime     time.Duration `yaml:"slowSqlTime"`
-	PrintSql        bool          `yaml:"printSql"`
+	DSN             string                 `json:"dsn"`
+	MaxIdleConn     int                    `yaml:"maxIdleConn"`
+	MaxOpenConn     int                    `yaml:"maxOpenConn"`
+	ConnMaxLifeTime time.Duration          `yaml:"connMaxLifeTime"`
+	ConnMaxIdleTime time.Duration          `yaml:"connMaxIdleTime"`
+	SlowSqlTime     time.Duration          `yaml:"slowSqlTime"`
+	SlowSqlCapacity int                    `yaml:"slowSqlCapacity"`
+	SlowSqlExplain  bool                   `yaml:"slowSqlExplain"`
+	SlowSqlPersist  bool                   `yaml:"slowSqlPersist"`
+	SlowSqlVars     bool                   `yaml:"slowSqlVars"`
+	PrintSql        bool                   `yaml:"printSql"`
+	MultiTenant     bool                   `yaml:"multiTenant"`
+	Encryption      types.EncryptionConfig `yaml:"encryption"`
 }
 
 type Gorm struct {
 	*gorm.DB
-	Logger         func(level string, caller string, format string, args ...any)
+	Logger         func(level string, entry SQLEntry)
+	config         Config
 	namingStrategy schema.NamingStrategy
 }
 
-func (orm *Gorm) Printf(format string, args ...any) {
-	level := "DEBUG"
-	caller := ""
-	if orm.Logger != nil && len(args) >= 2 {
-		caller = args[0].(string)
-		args = args[1:]
-		index := strings.Index(format, "%s")
-		format = strings.ReplaceAll(format, "\n", " ")[index+2:]
-		format = strings.TrimPrefix(format, " ")
-		switch tp := args[0].(type) {
-		case error:
-			if errors.Is(tp, gorm.ErrRecordNotFound) {
-				level = "DEBUG"
-				format = format[strings.Index(format, " ")+1:]
-				args = args[1:]
-			} else {
-				level = "ERROR"
-			}
-		case string:
-			if strings.Contains(tp, "SLOW SQL") {
-				level = "WARN"
-			}
-		}
-	}
-	orm.Logger(level, caller, format, args...)
+func (orm *Gorm) Transaction(fn func(tx *gorm.DB) error) error {
+	return orm.DB.Transaction(fn)
 }
 
-func (orm *Gorm) Transaction(fn func(tx *gorm.DB) error) {
-	tx := orm.Begin()
-	defer func() {
-		if err := recover(); err != nil {
-			tx.Rollback()
-			panic(err)
-		}
-	}()
-	err := fn(tx)
-	if err != nil {
-		tx.Rollback()
-	} else {
-		tx.Commit()
-	}
+func (orm *Gorm) Transactional(ctx context.Context, fn func(ctx context.Context) error, propagation ...transaction.Propagation) error {
+	return transaction.NewManager(orm.DB).Execute(ctx, fn, propagation...)
+}
+
+func (orm *Gorm) EnableMetrics(name string) error {
+	return metrics.Register(name, orm.DB)
+}
+
+func (orm *Gorm) EnableSlowLog(name string) error {
+	config := orm.config
+	return slowlog.Register(name, orm.DB, slowlog.Config{
+		Threshold: cond.Ternary(config.SlowSqlTime <= 0, defaultSlowSqlTime, config.SlowSqlTime),
+		Capacity:  config.SlowSqlCapacity,
+		Explain:   config.SlowSqlExplain,
+		Persist:   config.SlowSqlPersist,
+		Vars:      config.SlowSqlVars,
+	})
 }
 
 func (orm *Gorm) TableName(model any) string {
@@ -134,7 +124,7 @@ func New(dialector func(dsn string) gorm.Dialector, config Config) *Gorm {
 	}
 	dsn := config.DSN
 	if str.NonBlank(dsn) {
-		orm := &Gorm{}
+		orm := &Gorm{config: config}
 		namingStrategy := schema.NamingStrategy{
 			SingularTable: true,
 		}
@@ -150,13 +140,11 @@ func New(dialector func(dsn string) gorm.Dialector, config Config) *Gorm {
 		}
 		if config.PrintSql {
 			slowSqlTime := config.SlowSqlTime
-			sqlLogger := logger.New(orm, logger.Config{
-				SlowThreshold:             cond.Ternary(slowSqlTime <= 0, defaultSlowSqlTime, slowSqlTime),
-				Colorful:                  true,
-				IgnoreRecordNotFoundError: true,
-				LogLevel:                  logger.Info,
-			})
-			gormConfig.Logger = sqlLogger
+			gormConfig.Logger = &sqlLogger{
+				orm:           orm,
+				level:         logger.Info,
+				slowThreshold: cond.Ternary(slowSqlTime <= 0, defaultSlowSqlTime, slowSqlTime),
+			}
 		}
 		gormDB, err := gorm.Open(dialector(dsn), gormConfig)
 		errs.Panic(err)
@@ -171,6 +159,13 @@ func New(dialector func(dsn string) gorm.Dialector, config Config) *Gorm {
 		db.SetConnMaxLifetime(cond.Ternary(connMaxLifeTime <= 0, defaultConnMaxLifetime, connMaxLifeTime))
 		db.SetConnMaxIdleTime(cond.Ternary(connMaxIdleTime <= 0, defaultConnMaxIdleTime, connMaxIdleTime))
 		orm.DB = gormDB
+		if config.MultiTenant {
+			errs.Panic(orm.EnableTenant())
+		}
+		if len(config.Encryption.Keys) > 0 {
+			errs.Panic(types.SetEncryption(config.Encryption))
+			errs.Panic(orm.EnableEncryption())
+		}
 		return orm
 	}
 	panic("dsn is empty")
@@ -0,0 +1,176 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"github.com/misakacoder/inuyasha/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"slices"
+)
+
+const ignoreTenantKey = "tenant:ignore"
+
+var (
+	ErrTenantRawSQL = errors.New("raw sql on tenant-scoped model requires CrossTenant")
+	ErrTenantUpsert = errors.New("upsert on tenant-scoped model is not supported by this dialect")
+)
+
+func (orm *Gorm) EnableTenant() error {
+	return RegisterTenant(orm.DB)
+}
+
+// RegisterTenant scopes create, query, update, delete and row statements of
+// tenant.TenantScoped models to the tenant in the statement context. Raw SQL
+// cannot be rewritten: Raw/Exec on a tenant-scoped model fails unless the
+// statement is marked with CrossTenant, and plain db.Exec/db.Raw without a
+// model is not checked at all.
+func RegisterTenant(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Raw().Before("gorm:raw").Register("tenant:raw", tenantRaw); err != nil {
+		return err
+	}
+	if err := callback.Create().Before("gorm:create").Register("tenant:create", tenantCreate); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("tenant:query", tenantWhere); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("tenant:update", tenantWhere); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("tenant:delete", tenantWhere); err != nil {
+		return err
+	}
+	return callback.Row().Before("gorm:row").Register("tenant:row", tenantWhere)
+}
+
+func CrossTenant(db *gorm.DB) *gorm.DB {
+	return db.Set(ignoreTenantKey, true)
+}
+
+func tenantCreate(db *gorm.DB) {
+	column, tenantID, ok := resolveTenant(db)
+	if !ok {
+		return
+	}
+	stmt := db.Statement
+	field := stmt.Schema.LookUpField(column)
+	if field == nil {
+		return
+	}
+	ctx := stmtContext(stmt)
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			value := reflect.Indirect(stmt.ReflectValue.Index(i))
+			if value.Kind() == reflect.Map {
+				setTenantValue(stmt, value, field, tenantID)
+				continue
+			}
+			if err := field.Set(ctx, value, tenantID); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	case reflect.Map:
+		setTenantValue(stmt, stmt.ReflectValue, field, tenantID)
+	case reflect.Struct:
+		if err := field.Set(ctx, stmt.ReflectValue, tenantID); err != nil {
+			db.AddError(err)
+			return
+		}
+	}
+	tenantOnConflict(db, field, tenantID)
+}
+
+func setTenantValue(stmt *gorm.Statement, value reflect.Value, field *schema.Field, tenantID string) {
+	for _, key := range value.MapKeys() {
+		if key.Kind() == reflect.String && stmt.Schema.LookUpField(key.String()) == field {
+			value.SetMapIndex(key, reflect.Value{})
+		}
+	}
+	value.SetMapIndex(reflect.ValueOf(field.DBName).Convert(value.Type().Key()), reflect.ValueOf(tenantID))
+}
+
+func tenantOnConflict(db *gorm.DB, field *schema.Field, tenantID string) {
+	stmt := db.Statement
+	c, ok := stmt.Clauses["ON CONFLICT"]
+	if !ok {
+		return
+	}
+	onConflict, ok := c.Expression.(clause.OnConflict)
+	if !ok || onConflict.DoNothing || (len(onConflict.DoUpdates) == 0 && !onConflict.UpdateAll) {
+		return
+	}
+	switch db.Dialector.Name() {
+	case "postgres", "sqlite":
+	default:
+		db.AddError(ErrTenantUpsert)
+		return
+	}
+	if len(onConflict.Columns) > 0 && !slices.ContainsFunc(onConflict.Columns, func(column clause.Column) bool {
+		return column.Name == field.DBName
+	}) {
+		onConflict.Columns = append([]clause.Column{{Name: field.DBName}}, onConflict.Columns...)
+	}
+	onConflict.Where.Exprs = append(onConflict.Where.Exprs, clause.Eq{
+		Column: clause.Column{Table: stmt.Table, Name: field.DBName},
+		Value:  tenantID,
+	})
+	c.Expression = onConflict
+	stmt.Clauses["ON CONFLICT"] = c
+}
+
+func tenantWhere(db *gorm.DB) {
+	column, tenantID, ok := resolveTenant(db)
+	if !ok {
+		return
+	}
+	if db.Statement.SQL.Len() > 0 {
+		db.AddError(ErrTenantRawSQL)
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: tenantID},
+	}})
+}
+
+func tenantRaw(db *gorm.DB) {
+	if _, _, ok := resolveTenant(db); ok {
+		db.AddError(ErrTenantRawSQL)
+	}
+}
+
+func resolveTenant(db *gorm.DB) (string, string, bool) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return "", "", false
+	}
+	scoped, ok := reflect.New(stmt.Schema.ModelType).Interface().(tenant.TenantScoped)
+	if !ok {
+		return "", "", false
+	}
+	if ignored, _ := db.Get(ignoreTenantKey); ignored == true {
+		return "", "", false
+	}
+	ctx := stmtContext(stmt)
+	if tenant.IsIgnored(ctx) {
+		return "", "", false
+	}
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		db.AddError(tenant.ErrTenantMissing)
+		return "", "", false
+	}
+	return scoped.TenantColumn(), tenantID, true
+}
+
+func stmtContext(stmt *gorm.Statement) context.Context {
+	if stmt.Context == nil {
+		return context.Background()
+	}
+	return stmt.Context
+}
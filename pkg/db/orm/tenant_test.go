@@ -0,0 +1,152 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"github.com/misakacoder/inuyasha/internal/dbtest"
+	"github.com/misakacoder/inuyasha/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"testing"
+)
+
+type tenantAccount struct {
+	tenant.Model
+	ID   int64
+	Code string
+	Name string
+}
+
+func openTenant(t *testing.T, dialect string) (*gorm.DB, *dbtest.Recorder) {
+	db, recorder := dbtest.Open(t, dialect)
+	if err := RegisterTenant(db); err != nil {
+		t.Fatal(err)
+	}
+	return db.WithContext(tenant.WithTenant(context.Background(), "t1")), recorder
+}
+
+func lastStatement(recorder *dbtest.Recorder) dbtest.Statement {
+	statements := recorder.Statements()
+	for i := len(statements) - 1; i >= 0; i-- {
+		if statements[i].SQL != "BEGIN" && statements[i].SQL != "COMMIT" {
+			return statements[i]
+		}
+	}
+	return dbtest.Statement{}
+}
+
+func TestTenantUpsert(t *testing.T) {
+	tests := []struct {
+		name       string
+		dialect    string
+		onConflict clause.OnConflict
+		contains   []string
+		err        error
+	}{
+		{
+			name:       "postgres update columns",
+			dialect:    "postgres",
+			onConflict: clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoUpdates: clause.AssignmentColumns([]string{"name"})},
+			contains:   []string{`ON CONFLICT ("tenant_id","code") DO UPDATE SET "name"="excluded"."name" WHERE "tenant_accounts"."tenant_id" = `},
+		},
+		{
+			name:       "postgres update all",
+			dialect:    "postgres",
+			onConflict: clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, UpdateAll: true},
+			contains:   []string{`ON CONFLICT ("tenant_id","code") DO UPDATE SET`, `WHERE "tenant_accounts"."tenant_id" = `},
+		},
+		{
+			name:       "do nothing is untouched",
+			dialect:    "mysql",
+			onConflict: clause.OnConflict{DoNothing: true},
+			contains:   []string{"ON CONFLICT DO NOTHING"},
+		},
+		{
+			name:       "mysql update is rejected",
+			dialect:    "mysql",
+			onConflict: clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, UpdateAll: true},
+			err:        ErrTenantUpsert,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, recorder := openTenant(t, test.dialect)
+			err := db.Clauses(test.onConflict).Create(&tenantAccount{ID: 1, Code: "c", Name: "n"}).Error
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("err = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			statement := lastStatement(recorder)
+			for _, fragment := range test.contains {
+				if !strings.Contains(statement.SQL, fragment) {
+					t.Fatalf("sql %q does not contain %q", statement.SQL, fragment)
+				}
+			}
+			if statement.Args[len(statement.Args)-1] != "t1" && test.onConflict.DoNothing == false {
+				t.Fatalf("tenant not bound in conflict predicate: %v", statement.Args)
+			}
+		})
+	}
+}
+
+func TestTenantCreateMap(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"map", map[string]any{"id": 1, "code": "c"}},
+		{"map overriding tenant", map[string]any{"id": 1, "code": "c", "TenantID": "t2"}},
+		{"slice of maps", []map[string]any{{"id": 1, "code": "c"}, {"id": 2, "code": "d", "tenant_id": "t2"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, recorder := openTenant(t, "postgres")
+			if err := db.Model(&tenantAccount{}).Create(test.value).Error; err != nil {
+				t.Fatal(err)
+			}
+			statement := lastStatement(recorder)
+			if strings.Count(statement.SQL, `"tenant_id"`) != 1 {
+				t.Fatalf("tenant column missing or duplicated: %s", statement.SQL)
+			}
+			for _, arg := range statement.Args {
+				if arg == "t2" {
+					t.Fatalf("foreign tenant written: %v", statement.Args)
+				}
+			}
+		})
+	}
+}
+
+func TestTenantRaw(t *testing.T) {
+	db, _ := openTenant(t, "postgres")
+	if err := db.Model(&tenantAccount{}).Exec("delete from tenant_accounts").Error; !errors.Is(err, ErrTenantRawSQL) {
+		t.Fatalf("exec: err = %v", err)
+	}
+	var count int64
+	if err := db.Model(&tenantAccount{}).Raw("select count(*) from tenant_accounts").Scan(&count).Error; !errors.Is(err, ErrTenantRawSQL) {
+		t.Fatalf("raw: err = %v", err)
+	}
+	if err := CrossTenant(db).Model(&tenantAccount{}).Exec("delete from tenant_accounts").Error; err != nil {
+		t.Fatalf("cross tenant: %v", err)
+	}
+}
+
+func TestTenantWhere(t *testing.T) {
+	db, recorder := openTenant(t, "postgres")
+	var accounts []tenantAccount
+	if err := db.Where("code = ?", "c").Find(&accounts).Error; err != nil {
+		t.Fatal(err)
+	}
+	if statement := lastStatement(recorder); !strings.Contains(statement.SQL, `"tenant_accounts"."tenant_id" = `) {
+		t.Fatalf("tenant predicate missing: %s", statement.SQL)
+	}
+	if err := db.WithContext(context.Background()).Find(&accounts).Error; !errors.Is(err, tenant.ErrTenantMissing) {
+		t.Fatalf("missing tenant: err = %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/misakacoder/inuyasha/pkg/cache"
+	"github.com/misakacoder/inuyasha/pkg/db/transaction"
+	"github.com/misakacoder/inuyasha/pkg/db/util"
+	"github.com/misakacoder/inuyasha/pkg/tenant"
+	"gorm.io/gorm"
+	"reflect"
+	"time"
+)
+
+type CachedRepository[M any] struct {
+	*Repository[M]
+	cache   cache.Cache
+	codec   cache.Codec
+	ttl     time.Duration
+	prefix  string
+	group   *cache.Group
+	pending *[]string
+}
+
+func (repository *CachedRepository[M]) PrimaryKey(id uint) (M, error) {
+	if repository.pending != nil || transaction.InTransaction(repository.DB.Statement.Context) {
+		return repository.Repository.PrimaryKey(id)
+	}
+	key := repository.key(id)
+	if result, ok, err := cache.Get[M](repository.cache, repository.codec, key); err == nil && ok {
+		return result, nil
+	}
+	value, err := repository.group.Do(key, func() (any, error) {
+		result, err := repository.Repository.PrimaryKey(id)
+		if err != nil {
+			return result, err
+		}
+		_ = cache.Set(repository.cache, repository.codec, key, result, repository.ttl)
+		return result, nil
+	})
+	result, _ := value.(M)
+	return result, err
+}
+
+func (repository *CachedRepository[M]) Update(model *M, fields ...string) error {
+	err := repository.Repository.Update(model, fields...)
+	repository.evictModels(model)
+	return err
+}
+
+func (repository *CachedRepository[M]) Updates(model *M, conditions []any, fields ...string) error {
+	ids, err := repository.ids(conditions)
+	if err != nil {
+		return err
+	}
+	err = repository.Repository.Updates(model, conditions, fields...)
+	repository.evict(ids...)
+	return err
+}
+
+func (repository *CachedRepository[M]) UpdateBatch(models []*M, fields ...string) ([]int64, error) {
+	rowsAffected, err := repository.Repository.UpdateBatch(models, fields...)
+	repository.evictModels(models...)
+	return rowsAffected, err
+}
+
+func (repository *CachedRepository[M]) Upsert(models []*M, conflictColumns []string, updateColumns []string) ([]int64, error) {
+	rowsAffected, err := repository.Repository.Upsert(models, conflictColumns, updateColumns)
+	repository.evictModels(models...)
+	return rowsAffected, err
+}
+
+func (repository *CachedRepository[M]) Delete(id uint) error {
+	err := repository.Repository.Delete(id)
+	repository.evict(id)
+	return err
+}
+
+func (repository *CachedRepository[M]) Deletes(model *M) error {
+	ids, err := repository.ids([]any{model})
+	if err != nil {
+		return err
+	}
+	err = repository.Repository.Deletes(model)
+	repository.evict(ids...)
+	return err
+}
+
+func (repository *CachedRepository[M]) Transaction(fn func(*CachedRepository[M]) error) error {
+	var pending []string
+	err := repository.Repository.Transaction(func(repo *Repository[M]) error {
+		return fn(&CachedRepository[M]{
+			Repository: repo,
+			cache:      repository.cache,
+			codec:      repository.codec,
+			ttl:        repository.ttl,
+			prefix:     repository.prefix,
+			group:      repository.group,
+			pending:    &pending,
+		})
+	})
+	repository.delete(pending...)
+	return err
+}
+
+func (repository *CachedRepository[M]) Evict(ids ...uint) {
+	repository.evict(ids...)
+}
+
+func (repository *CachedRepository[M]) ids(conditions []any) ([]uint, error) {
+	var mod M
+	var ids []uint
+	stmt := &gorm.Statement{DB: repository.DB}
+	if err := stmt.Parse(&mod); err != nil {
+		return nil, err
+	}
+	primaryKey := stmt.Schema.PrioritizedPrimaryField
+	if primaryKey == nil {
+		return nil, nil
+	}
+	err := util.AddWhere(repository.DB.Model(&mod), conditions).Pluck(primaryKey.DBName, &ids).Error
+	return ids, err
+}
+
+func (repository *CachedRepository[M]) evictModels(models ...*M) {
+	stmt := &gorm.Statement{DB: repository.DB}
+	var mod M
+	if err := stmt.Parse(&mod); err != nil {
+		return
+	}
+	primaryKey := stmt.Schema.PrioritizedPrimaryField
+	if primaryKey == nil {
+		return
+	}
+	var keys []string
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		if id, zero := primaryKey.ValueOf(context.Background(), reflect.ValueOf(model).Elem()); !zero {
+			keys = append(keys, fmt.Sprintf("%s:%v", repository.keyPrefix(), id))
+		}
+	}
+	repository.delete(keys...)
+}
+
+func (repository *CachedRepository[M]) evict(ids ...uint) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = repository.key(id)
+	}
+	repository.delete(keys...)
+}
+
+func (repository *CachedRepository[M]) delete(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if repository.pending != nil {
+		*repository.pending = append(*repository.pending, keys...)
+		return
+	}
+	_ = repository.cache.Delete(keys...)
+	if ctx := repository.DB.Statement.Context; transaction.InTransaction(ctx) {
+		transaction.AfterCommit(ctx, func() {
+			_ = repository.cache.Delete(keys...)
+		})
+	}
+}
+
+func (repository *CachedRepository[M]) key(id uint) string {
+	return fmt.Sprintf("%s:%d", repository.keyPrefix(), id)
+}
+
+func (repository *CachedRepository[M]) keyPrefix() string {
+	if tenantID, ok := tenant.FromContext(repository.DB.Statement.Context); ok {
+		return fmt.Sprintf("%s:%s", repository.prefix, tenantID)
+	}
+	return repository.prefix
+}
+
+func (repository *CachedRepository[M]) WithContext(ctx context.Context) *CachedRepository[M] {
+	return &CachedRepository[M]{
+		Repository: repository.Repository.WithContext(ctx),
+		cache:      repository.cache,
+		codec:      repository.codec,
+		ttl:        repository.ttl,
+		prefix:     repository.prefix,
+		group:      repository.group,
+		pending:    repository.pending,
+	}
+}
+
+// NewCached caches PrimaryKey lookups of repository in store. Models are
+// serialized with cache.GobCodec unless another codec is given.
+func NewCached[M any](repository *Repository[M], store cache.Cache, ttl time.Duration, codec ...cache.Codec) *CachedRepository[M] {
+	var mod M
+	prefix := fmt.Sprintf("%T", mod)
+	stmt := &gorm.Statement{DB: repository.DB}
+	if err := stmt.Parse(&mod); err == nil {
+		prefix = stmt.Schema.Table
+	}
+	cached := &CachedRepository[M]{
+		Repository: repository,
+		cache:      store,
+		codec:      cache.GobCodec,
+		ttl:        ttl,
+		prefix:     prefix,
+		group:      &cache.Group{},
+	}
+	if len(codec) > 0 {
+		cached.codec = codec[0]
+	}
+	return cached
+}
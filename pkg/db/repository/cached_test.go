@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"github.com/misakacoder/inuyasha/internal/dbtest"
+	"github.com/misakacoder/inuyasha/pkg/cache"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+type cachedAccount struct {
+	ID        uint
+	Name      string
+	Secret    string `json:"-"`
+	Balance   types.Decimal
+	CreatedAt types.DateTime
+	ClosedAt  types.NullDateTime
+}
+
+type countingCodec struct {
+	cache.Codec
+	calls int
+}
+
+func (codec *countingCodec) Marshal(v any) ([]byte, error) {
+	codec.calls++
+	return codec.Codec.Marshal(v)
+}
+
+func TestCachedPrimaryKey(t *testing.T) {
+	zone := time.FixedZone("UTC+9", 9*60*60)
+	createdAt := time.Date(2024, 3, 4, 5, 6, 7, 123456789, zone)
+	codec := &countingCodec{Codec: cache.GobCodec}
+	tests := []struct {
+		name  string
+		codec []cache.Codec
+	}{
+		{name: "gob"},
+		{name: "custom codec", codec: []cache.Codec{codec}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, recorder := dbtest.Open(t, "mysql")
+			queries := 0
+			recorder.Query = func(query string, args []any) dbtest.Result {
+				queries++
+				return dbtest.Result{
+					Columns: []string{"id", "name", "secret", "balance", "created_at", "closed_at"},
+					Rows:    [][]driver.Value{{int64(1), "a", "s3cret", "12.3400", createdAt, createdAt.Add(time.Hour)}},
+				}
+			}
+			repository := NewCached(New[cachedAccount](db), cache.NewLRU(16), time.Minute, test.codec...)
+			fromDB, err := repository.PrimaryKey(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cached, err := repository.PrimaryKey(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if queries != 1 {
+				t.Fatalf("queries = %d, want 1: %s", queries, strings.Join(recorder.SQL(), "; "))
+			}
+			if cached.ID != fromDB.ID || cached.Name != fromDB.Name || cached.Secret != fromDB.Secret || cached.Secret != "s3cret" {
+				t.Fatalf("cached = %+v, want %+v", cached, fromDB)
+			}
+			if cached.Balance.String() != fromDB.Balance.String() {
+				t.Fatalf("balance = %s, want %s", cached.Balance, fromDB.Balance)
+			}
+			for _, pair := range [][2]time.Time{{cached.CreatedAt.Time(), fromDB.CreatedAt.Time()}, {cached.ClosedAt.Time(), fromDB.ClosedAt.Time()}} {
+				_, cachedOffset := pair[0].Zone()
+				_, offset := pair[1].Zone()
+				if !pair[0].Equal(pair[1]) || cachedOffset != offset {
+					t.Fatalf("time = %s, want %s", pair[0].Format(time.RFC3339Nano), pair[1].Format(time.RFC3339Nano))
+				}
+			}
+			if !cached.ClosedAt.Valid {
+				t.Fatal("closed at lost its validity")
+			}
+			if len(test.codec) > 0 && codec.calls != 1 {
+				t.Fatalf("codec calls = %d, want 1", codec.calls)
+			}
+		})
+	}
+}
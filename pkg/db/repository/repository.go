@@ -1,15 +1,26 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"github.com/misakacoder/inuyasha/pkg/db/transaction"
 	"github.com/misakacoder/inuyasha/pkg/db/util"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"strings"
+	"time"
 )
 
 type Repository[M any] struct {
 	DB *gorm.DB
 }
 
+func (repository *Repository[M]) WithContext(ctx context.Context) *Repository[M] {
+	return &Repository[M]{DB: transaction.DB(ctx, repository.DB)}
+}
+
 func (repository *Repository[M]) Create(model ...*M) error {
 	return repository.DB.Create(model).Error
 }
@@ -18,6 +29,93 @@ func (repository *Repository[M]) CreateOnConflictDoNothing(models ...*M) error {
 	return repository.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(models).Error
 }
 
+func (repository *Repository[M]) Upsert(models []*M, conflictColumns []string, updateColumns []string) ([]int64, error) {
+	onConflict := clause.OnConflict{}
+	for _, column := range conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+	if len(updateColumns) > 0 {
+		onConflict.DoUpdates = clause.AssignmentColumns(updateColumns)
+	} else {
+		onConflict.UpdateAll = true
+	}
+	return repository.batch(models, func(tx *gorm.DB, batch []*M) (int64, error) {
+		result := tx.Clauses(onConflict).Create(batch)
+		return result.RowsAffected, result.Error
+	})
+}
+
+func (repository *Repository[M]) UpdateBatch(models []*M, fields ...string) ([]int64, error) {
+	var mod M
+	stmt := &gorm.Statement{DB: repository.DB}
+	if err := stmt.Parse(&mod); err != nil {
+		return nil, err
+	}
+	primaryKey := stmt.Schema.PrioritizedPrimaryField
+	if primaryKey == nil {
+		return nil, fmt.Errorf("%s has no primary key", stmt.Schema.Name)
+	}
+	updateFields, err := updatableFields(stmt.Schema, fields)
+	if err != nil {
+		return nil, err
+	}
+	ctx := repository.DB.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	primaryKeyColumn := stmt.Quote(primaryKey.DBName)
+	cast := repository.DB.Dialector.Name() == "postgres"
+	return repository.batch(models, func(tx *gorm.DB, batch []*M) (int64, error) {
+		now := tx.NowFunc()
+		ids := make([]any, 0, len(batch))
+		values := make([]reflect.Value, 0, len(batch))
+		for _, model := range batch {
+			if model == nil {
+				continue
+			}
+			value := reflect.ValueOf(model).Elem()
+			id, zero := primaryKey.ValueOf(ctx, value)
+			if zero {
+				return 0, fmt.Errorf("%s primary key is empty", stmt.Schema.Name)
+			}
+			ids = append(ids, id)
+			values = append(values, value)
+		}
+		if len(ids) == 0 {
+			return 0, nil
+		}
+		updates := make(map[string]any, len(updateFields))
+		for _, field := range updateFields {
+			if field.AutoUpdateTime > 0 {
+				updateTime := autoUpdateTime(field, now)
+				for _, value := range values {
+					if err := field.Set(ctx, value, updateTime); err != nil {
+						return 0, err
+					}
+				}
+				updates[field.DBName] = updateTime
+				continue
+			}
+			placeholder := "?"
+			if cast {
+				placeholder = fmt.Sprintf("cast(? as %s)", tx.Dialector.DataTypeOf(field))
+			}
+			sql := strings.Builder{}
+			sql.WriteString(fmt.Sprintf("case %s", primaryKeyColumn))
+			args := make([]any, 0, len(ids)*2)
+			for i, value := range values {
+				fieldValue, _ := field.ValueOf(ctx, value)
+				sql.WriteString(" when ? then " + placeholder)
+				args = append(args, ids[i], fieldValue)
+			}
+			sql.WriteString(" end")
+			updates[field.DBName] = gorm.Expr(sql.String(), args...)
+		}
+		result := tx.Model(&mod).Where(fmt.Sprintf("%s in ?", primaryKeyColumn), ids).UpdateColumns(updates)
+		return result.RowsAffected, result.Error
+	})
+}
+
 func (repository *Repository[M]) Update(model *M, fields ...string) error {
 	return repository.DB.Select(fields).Updates(model).Error
 }
@@ -100,21 +198,67 @@ func (repository *Repository[M]) Counts(conditions []any) (int64, error) {
 }
 
 func (repository *Repository[M]) Transaction(fn func(*Repository[M]) error) error {
-	tx := repository.DB.Begin()
-	defer func() {
-		if err := recover(); err != nil {
-			tx.Rollback()
-			panic(err)
+	return repository.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&Repository[M]{DB: tx})
+	})
+}
+
+func (repository *Repository[M]) batch(models []*M, fn func(tx *gorm.DB, batch []*M) (int64, error)) ([]int64, error) {
+	batchSize := repository.DB.CreateBatchSize
+	if batchSize <= 0 {
+		batchSize = len(models)
+	}
+	var rowsAffected []int64
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		for begin := 0; begin < len(models); begin += batchSize {
+			end := min(begin+batchSize, len(models))
+			rows, err := fn(tx, models[begin:end])
+			if err != nil {
+				return err
+			}
+			rowsAffected = append(rowsAffected, rows)
 		}
-	}()
-	repo := &Repository[M]{DB: tx}
-	err := fn(repo)
+		return nil
+	})
 	if err != nil {
-		tx.Rollback()
-	} else {
-		err = tx.Commit().Error
+		return nil, err
+	}
+	return rowsAffected, nil
+}
+
+func autoUpdateTime(field *schema.Field, now time.Time) any {
+	if field.DataType != schema.Int && field.DataType != schema.Uint {
+		return now
+	}
+	switch field.AutoUpdateTime {
+	case schema.UnixNanosecond:
+		return now.UnixNano()
+	case schema.UnixMillisecond:
+		return now.UnixMilli()
+	}
+	return now.Unix()
+}
+
+func updatableFields(sch *schema.Schema, fields []string) ([]*schema.Field, error) {
+	var result []*schema.Field
+	if len(fields) == 0 {
+		for _, field := range sch.Fields {
+			if field.DBName != "" && field.Updatable && !field.PrimaryKey && field.AutoCreateTime == 0 {
+				result = append(result, field)
+			}
+		}
+		return result, nil
+	}
+	for _, name := range fields {
+		field := sch.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("%s has no field %s", sch.Name, name)
+		}
+		if !field.PrimaryKey {
+			result = append(result, field)
+		}
 	}
-	return err
+	return result, nil
 }
 
 func New[M any](db *gorm.DB) *Repository[M] {
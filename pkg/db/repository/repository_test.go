@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+	"github.com/misakacoder/inuyasha/internal/dbtest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type account struct {
+	ID        int64
+	Name      string
+	Balance   int64
+	UpdatedAt time.Time
+}
+
+func TestUpdateBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		cast    bool
+	}{
+		{"postgres casts values", "postgres", true},
+		{"mysql keeps plain placeholders", "mysql", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, recorder := dbtest.Open(t, test.dialect)
+			stale := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+			models := []*account{{ID: 1, Name: "a", Balance: 10, UpdatedAt: stale}, {ID: 2, Name: "b", Balance: 20, UpdatedAt: stale}}
+			if _, err := New[account](db).UpdateBatch(models); err != nil {
+				t.Fatal(err)
+			}
+			statements := recorder.Statements()
+			if len(statements) != 3 || statements[0].SQL != "BEGIN" || statements[2].SQL != "COMMIT" {
+				t.Fatalf("expected one transaction, got %v", recorder.SQL())
+			}
+			update := statements[1]
+			if got := strings.Contains(update.SQL, "cast("); got != test.cast {
+				t.Fatalf("cast = %v, sql: %s", got, update.SQL)
+			}
+			for _, model := range models {
+				if !model.UpdatedAt.After(stale) {
+					t.Fatalf("updated_at not refreshed: %v", model.UpdatedAt)
+				}
+			}
+			for _, arg := range update.Args {
+				if tm, ok := arg.(time.Time); ok && !tm.After(stale) {
+					t.Fatalf("stale updated_at written: %v", tm)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchRollsBack(t *testing.T) {
+	db, recorder := dbtest.Open(t, "postgres")
+	db.CreateBatchSize = 1
+	calls := 0
+	recorder.Fail = func(query string, args []any) error {
+		if strings.HasPrefix(query, "UPDATE") {
+			if calls++; calls == 2 {
+				return errors.New("boom")
+			}
+		}
+		return nil
+	}
+	models := []*account{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+	if _, err := New[account](db).UpdateBatch(models, "Name"); err == nil {
+		t.Fatal("expected error")
+	}
+	sql := recorder.SQL()
+	if sql[0] != "BEGIN" || sql[len(sql)-1] != "ROLLBACK" {
+		t.Fatalf("expected rollback, got %v", sql)
+	}
+}
@@ -0,0 +1,363 @@
+package slowlog
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"github.com/misakacoder/inuyasha/pkg/function"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCapacity = 200
+	startTimeKey    = "slowlog:start"
+)
+
+var (
+	mutex     sync.RWMutex
+	recorders []*recorder
+	patterns  = []struct {
+		regexp  *regexp.Regexp
+		replace string
+	}{
+		{regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`), "?"},
+		{regexp.MustCompile(`\b\d+(?:\.\d+)?\b`), "?"},
+		{regexp.MustCompile(`\$\d+`), "?"},
+		{regexp.MustCompile(`(?i)\bin\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`), "in (?)"},
+		{regexp.MustCompile(`\s+`), " "},
+	}
+)
+
+type skipKey struct{}
+
+type Config struct {
+	Threshold time.Duration
+	Capacity  int
+	Explain   bool
+	Persist   bool
+	Vars      bool
+}
+
+type Record struct {
+	DB          string           `json:"db"`
+	Fingerprint string           `json:"fingerprint"`
+	SQL         string           `json:"sql"`
+	Vars        []any            `json:"vars,omitempty"`
+	Caller      string           `json:"caller"`
+	Duration    float64          `json:"duration"`
+	Rows        int64            `json:"rows"`
+	Error       string           `json:"error,omitempty"`
+	Explain     []map[string]any `json:"explain,omitempty"`
+	Time        types.DateTime   `json:"time"`
+	vars        []any
+}
+
+type Group struct {
+	Fingerprint string  `json:"fingerprint"`
+	Normalized  string  `json:"normalized"`
+	Count       int     `json:"count"`
+	Total       float64 `json:"total"`
+	Avg         float64 `json:"avg"`
+	Max         float64 `json:"max"`
+	Sample      Record  `json:"sample"`
+}
+
+type SlowQuery struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	DB          string         `json:"db" gorm:"size:64"`
+	Fingerprint string         `json:"fingerprint" gorm:"size:40;index"`
+	SQL         string         `json:"sql" gorm:"type:text"`
+	Vars        string         `json:"vars" gorm:"type:text"`
+	Caller      string         `json:"caller" gorm:"size:512"`
+	Duration    float64        `json:"duration"`
+	Rows        int64          `json:"rows"`
+	Error       string         `json:"error" gorm:"type:text"`
+	Explain     string         `json:"explain" gorm:"type:text"`
+	CreatedAt   types.DateTime `json:"createdAt"`
+}
+
+func (SlowQuery) TableComment() string {
+	return "慢SQL"
+}
+
+type recorder struct {
+	name    string
+	db      *gorm.DB
+	config  Config
+	mutex   sync.Mutex
+	records []*Record
+	next    int
+	full    bool
+}
+
+func Register(name string, db *gorm.DB, config Config) error {
+	if config.Capacity <= 0 {
+		config.Capacity = defaultCapacity
+	}
+	if config.Persist {
+		if err := db.AutoMigrate(&SlowQuery{}); err != nil {
+			return err
+		}
+	}
+	recorder := &recorder{
+		name:    name,
+		db:      db.Session(&gorm.Session{NewDB: true, Logger: logger.Discard}),
+		config:  config,
+		records: make([]*Record, config.Capacity),
+	}
+	callback := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callback.Create().Before("gorm:create").Register, callback.Create().After("gorm:create").Register},
+		{"query", callback.Query().Before("gorm:query").Register, callback.Query().After("gorm:query").Register},
+		{"update", callback.Update().Before("gorm:update").Register, callback.Update().After("gorm:update").Register},
+		{"delete", callback.Delete().Before("gorm:delete").Register, callback.Delete().After("gorm:delete").Register},
+		{"row", callback.Row().Before("gorm:row").Register, callback.Row().After("gorm:row").Register},
+		{"raw", callback.Raw().Before("gorm:raw").Register, callback.Raw().After("gorm:raw").Register},
+	}
+	for _, processor := range processors {
+		if err := processor.before("slowlog:before_"+processor.operation, before); err != nil {
+			return err
+		}
+		if err := processor.after("slowlog:after_"+processor.operation, recorder.after); err != nil {
+			return err
+		}
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	recorders = append(recorders, recorder)
+	return nil
+}
+
+func Records(sortBy string) []Record {
+	var result []Record
+	mutex.RLock()
+	for _, recorder := range recorders {
+		result = append(result, recorder.snapshot()...)
+	}
+	mutex.RUnlock()
+	if sortBy == "duration" {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Duration > result[j].Duration
+		})
+	} else {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Time.Time().After(result[j].Time.Time())
+		})
+	}
+	return result
+}
+
+func Groups(sortBy string) []Group {
+	groupMap := map[string]*Group{}
+	var groups []*Group
+	for _, record := range Records("time") {
+		group, ok := groupMap[record.Fingerprint]
+		if !ok {
+			group = &Group{Fingerprint: record.Fingerprint, Normalized: Normalize(record.SQL), Sample: record}
+			groupMap[record.Fingerprint] = group
+			groups = append(groups, group)
+		}
+		group.Count++
+		group.Total += record.Duration
+		if record.Duration > group.Max {
+			group.Max = record.Duration
+			group.Sample = record
+		}
+	}
+	result := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		group.Avg = group.Total / float64(group.Count)
+		result = append(result, *group)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		switch sortBy {
+		case "count":
+			return result[i].Count > result[j].Count
+		case "total":
+			return result[i].Total > result[j].Total
+		default:
+			return result[i].Max > result[j].Max
+		}
+	})
+	return result
+}
+
+func Normalize(sql string) string {
+	for _, pattern := range patterns {
+		sql = pattern.regexp.ReplaceAllString(sql, pattern.replace)
+	}
+	return strings.ToLower(strings.TrimSpace(sql))
+}
+
+func Fingerprint(sql string) string {
+	sum := sha1.Sum([]byte(Normalize(sql)))
+	return hex.EncodeToString(sum[:])
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func (recorder *recorder) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(startTimeKey)
+	if !ok {
+		return
+	}
+	start, ok := value.(time.Time)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start)
+	stmt := db.Statement
+	if elapsed < recorder.config.Threshold || stmt.SQL.Len() == 0 {
+		return
+	}
+	if ctx := stmt.Context; ctx != nil && ctx.Value(skipKey{}) != nil {
+		return
+	}
+	sql := stmt.SQL.String()
+	record := &Record{
+		DB:          recorder.name,
+		Fingerprint: Fingerprint(sql),
+		SQL:         sql,
+		vars:        append([]any{}, stmt.Vars...),
+		Caller:      utils.FileWithLineNum(),
+		Duration:    float64(elapsed) / float64(time.Millisecond),
+		Rows:        db.RowsAffected,
+		Time:        types.DateTimeFrom(start),
+	}
+	if recorder.config.Vars {
+		record.Vars = record.vars
+	}
+	if db.Error != nil {
+		record.Error = db.Error.Error()
+	}
+	recorder.push(record)
+	if recorder.config.Explain || recorder.config.Persist {
+		function.Async(func() {
+			recorder.explain(record)
+			recorder.persist(record)
+		})
+	}
+}
+
+func (recorder *recorder) push(record *Record) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.records[recorder.next] = record
+	recorder.next = (recorder.next + 1) % len(recorder.records)
+	if recorder.next == 0 {
+		recorder.full = true
+	}
+}
+
+func (recorder *recorder) snapshot() []Record {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	size := recorder.next
+	if recorder.full {
+		size = len(recorder.records)
+	}
+	result := make([]Record, 0, size)
+	for i := 0; i < size; i++ {
+		if record := recorder.records[i]; record != nil {
+			result = append(result, *record)
+		}
+	}
+	return result
+}
+
+func (recorder *recorder) explain(record *Record) {
+	if !recorder.config.Explain {
+		return
+	}
+	sql := strings.TrimSpace(record.SQL)
+	lower := strings.ToLower(sql)
+	if !strings.HasPrefix(lower, "select") && !strings.HasPrefix(lower, "with") {
+		return
+	}
+	prefix := "EXPLAIN "
+	switch recorder.db.Dialector.Name() {
+	case "sqlite":
+		prefix = "EXPLAIN QUERY PLAN "
+	case "sqlserver":
+		return
+	}
+	rows, err := recorder.query(prefix+sql, record.vars)
+	if err != nil {
+		rows = []map[string]any{{"error": err.Error()}}
+	}
+	recorder.mutex.Lock()
+	record.Explain = rows
+	recorder.mutex.Unlock()
+}
+
+func (recorder *recorder) query(sql string, vars []any) ([]map[string]any, error) {
+	rows, err := recorder.db.Statement.ConnPool.QueryContext(context.Background(), sql, vars...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]any
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if data, ok := values[i].([]byte); ok {
+				row[column] = string(data)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func (recorder *recorder) persist(record *Record) {
+	if !recorder.config.Persist {
+		return
+	}
+	recorder.mutex.Lock()
+	vars, _ := json.Marshal(record.Vars)
+	explain, _ := json.Marshal(record.Explain)
+	slowQuery := &SlowQuery{
+		DB:          record.DB,
+		Fingerprint: record.Fingerprint,
+		SQL:         record.SQL,
+		Vars:        string(vars),
+		Caller:      record.Caller,
+		Duration:    record.Duration,
+		Rows:        record.Rows,
+		Error:       record.Error,
+		Explain:     string(explain),
+		CreatedAt:   record.Time,
+	}
+	recorder.mutex.Unlock()
+	ctx := context.WithValue(context.Background(), skipKey{}, true)
+	_ = recorder.db.WithContext(ctx).Create(slowQuery).Error
+}
@@ -0,0 +1,85 @@
+package slowlog
+
+import (
+	"database/sql/driver"
+	"github.com/misakacoder/inuyasha/internal/dbtest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type order struct {
+	ID     int64
+	Status string
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT * FROM t WHERE id = 10", "select * from t where id = ?"},
+		{"select * from t where name = 'a''b'", "select * from t where name = ?"},
+		{"select * from t where id = $1 and x = $2", "select * from t where id = $? and x = $?"},
+		{"select * from t where id IN (?, ?,?)", "select * from t where id in (?)"},
+		{"select  *\n from t", "select * from t"},
+	}
+	for _, test := range tests {
+		if got := Normalize(test.sql); got != test.want {
+			t.Errorf("Normalize(%q) = %q, want %q", test.sql, got, test.want)
+		}
+	}
+}
+
+func TestExplainAndRedaction(t *testing.T) {
+	tests := []struct {
+		name string
+		vars bool
+	}{
+		{"redacted by default", false},
+		{"vars enabled", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, recorder := dbtest.Open(t, "postgres")
+			recorder.Query = func(query string, args []any) dbtest.Result {
+				if strings.HasPrefix(query, "EXPLAIN") {
+					return dbtest.Result{Columns: []string{"QUERY PLAN"}, Rows: [][]driver.Value{{[]byte("Seq Scan")}}}
+				}
+				return dbtest.Result{}
+			}
+			name := "explain-" + t.Name()
+			if err := Register(name, db, Config{Explain: true, Vars: test.vars}); err != nil {
+				t.Fatal(err)
+			}
+			var orders []order
+			if err := db.Where("status = ?", "paid").Find(&orders).Error; err != nil {
+				t.Fatal(err)
+			}
+			var explain dbtest.Statement
+			for deadline := time.Now().Add(time.Second); time.Now().Before(deadline) && explain.SQL == ""; time.Sleep(5 * time.Millisecond) {
+				for _, statement := range recorder.Statements() {
+					if strings.HasPrefix(statement.SQL, "EXPLAIN") {
+						explain = statement
+					}
+				}
+			}
+			if explain.SQL != `EXPLAIN SELECT * FROM "orders" WHERE status = $1` || !reflect.DeepEqual(explain.Args, []any{"paid"}) {
+				t.Fatalf("explain = %q %v", explain.SQL, explain.Args)
+			}
+			var record *Record
+			for _, r := range Records("time") {
+				if r.DB == name {
+					record = &r
+				}
+			}
+			if record == nil {
+				t.Fatal("record missing")
+			}
+			if got := record.Vars != nil; got != test.vars {
+				t.Fatalf("vars exposed = %v, want %v", got, test.vars)
+			}
+		})
+	}
+}
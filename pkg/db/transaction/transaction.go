@@ -0,0 +1,105 @@
+package transaction
+
+import (
+	"context"
+	"github.com/misakacoder/inuyasha/pkg/function"
+	"gorm.io/gorm"
+	"sync"
+)
+
+type Propagation int
+
+const (
+	Required Propagation = iota
+	RequiresNew
+	Nested
+)
+
+type stateKey struct{}
+
+type state struct {
+	tx          *gorm.DB
+	mutex       sync.Mutex
+	afterCommit []func()
+}
+
+func (state *state) addAfterCommit(callbacks ...func()) {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.afterCommit = append(state.afterCommit, callbacks...)
+}
+
+func (state *state) callbacks() []func() {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	return append([]func(){}, state.afterCommit...)
+}
+
+type Manager struct {
+	db *gorm.DB
+}
+
+func NewManager(db *gorm.DB) *Manager {
+	return &Manager{db: db}
+}
+
+func (manager *Manager) Execute(ctx context.Context, fn func(ctx context.Context) error, propagation ...Propagation) error {
+	mode := Required
+	if len(propagation) > 0 {
+		mode = propagation[0]
+	}
+	parent, active := current(ctx)
+	switch {
+	case active && mode == Required:
+		return fn(ctx)
+	case active && mode == Nested:
+		child := &state{}
+		err := parent.tx.Transaction(func(tx *gorm.DB) error {
+			child.tx = tx
+			return fn(context.WithValue(ctx, stateKey{}, child))
+		})
+		if err == nil {
+			parent.addAfterCommit(child.callbacks()...)
+		}
+		return err
+	}
+	root := &state{}
+	err := manager.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		root.tx = tx
+		return fn(context.WithValue(ctx, stateKey{}, root))
+	})
+	if err == nil {
+		for _, callback := range root.callbacks() {
+			function.Sync(callback)
+		}
+	}
+	return err
+}
+
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if state, ok := current(ctx); ok {
+		return state.tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+func InTransaction(ctx context.Context) bool {
+	_, ok := current(ctx)
+	return ok
+}
+
+func AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := current(ctx); ok {
+		state.addAfterCommit(fn)
+	} else {
+		function.Sync(fn)
+	}
+}
+
+func current(ctx context.Context) (*state, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	state, ok := ctx.Value(stateKey{}).(*state)
+	return state, ok && state.tx != nil
+}
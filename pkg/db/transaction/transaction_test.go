@@ -0,0 +1,58 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"github.com/misakacoder/inuyasha/internal/dbtest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAfterCommit(t *testing.T) {
+	tests := []struct {
+		name      string
+		fail      bool
+		nestedErr bool
+		want      int32
+	}{
+		{"committed", false, false, 101},
+		{"rolled back", true, false, 0},
+		{"nested rolled back", false, true, 100},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, _ := dbtest.Open(t, "postgres")
+			manager := NewManager(db)
+			var calls atomic.Int32
+			err := manager.Execute(context.Background(), func(ctx context.Context) error {
+				var wg sync.WaitGroup
+				for i := 0; i < 100; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						AfterCommit(ctx, func() { calls.Add(1) })
+					}()
+				}
+				wg.Wait()
+				_ = manager.Execute(ctx, func(ctx context.Context) error {
+					AfterCommit(ctx, func() { calls.Add(1) })
+					if test.nestedErr {
+						return errors.New("nested")
+					}
+					return nil
+				}, Nested)
+				if test.fail {
+					return errors.New("boom")
+				}
+				return nil
+			})
+			if (err != nil) != test.fail {
+				t.Fatalf("err = %v", err)
+			}
+			if got := calls.Load(); got != test.want {
+				t.Fatalf("callbacks = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type Bool bool
+
+func (b *Bool) Scan(v any) error {
+	switch value := v.(type) {
+	case nil:
+		*b = false
+	case bool:
+		*b = Bool(value)
+	case int64:
+		*b = value != 0
+	case float64:
+		*b = value != 0
+	case []byte:
+		if len(value) == 1 && value[0] <= 1 {
+			*b = value[0] == 1
+			return nil
+		}
+		return b.UnmarshalParam(string(value))
+	case string:
+		return b.UnmarshalParam(value)
+	default:
+		return fmt.Errorf("不能将%T转换为Bool", v)
+	}
+	return nil
+}
+
+func (b Bool) Value() (driver.Value, error) {
+	return bool(b), nil
+}
+
+func (b Bool) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bool(b))
+}
+
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" {
+		*b = false
+		return nil
+	}
+	return b.UnmarshalParam(text)
+}
+
+func (b *Bool) UnmarshalParam(param string) error {
+	switch strings.ToLower(strings.TrimSpace(param)) {
+	case "1", "t", "true", "y", "yes", "on":
+		*b = true
+	case "", "0", "f", "false", "n", "no", "off":
+		*b = false
+	default:
+		return fmt.Errorf("不能将%s转换为Bool", param)
+	}
+	return nil
+}
+
+func (b Bool) Bool() bool {
+	return bool(b)
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+type Date time.Time
+
+func (date *Date) Scan(v any) error {
+	newTime, err := scanTime(v, "Date", time.DateOnly)
+	if err != nil {
+		return err
+	}
+	*date = DateFrom(newTime)
+	return nil
+}
+
+func (date Date) Value() (driver.Value, error) {
+	return date.Time(), nil
+}
+
+func (date Date) GormDataType() string {
+	return "date"
+}
+
+func (date Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(date.String())
+}
+
+func (date *Date) UnmarshalJSON(data []byte) error {
+	var dateString string
+	if err := json.Unmarshal(data, &dateString); err != nil {
+		return err
+	}
+	return date.UnmarshalParam(dateString)
+}
+
+func (date *Date) UnmarshalParam(param string) error {
+	newTime, err := time.ParseInLocation(time.DateOnly, param, GetDateTimeFormat().location())
+	if err != nil {
+		return err
+	}
+	*date = Date(newTime)
+	return nil
+}
+
+func (date Date) GobEncode() ([]byte, error) {
+	return date.Time().GobEncode()
+}
+
+func (date *Date) GobDecode(data []byte) error {
+	var tm time.Time
+	if err := tm.GobDecode(data); err != nil {
+		return err
+	}
+	*date = Date(tm)
+	return nil
+}
+
+func (date Date) Time() time.Time {
+	return time.Time(date)
+}
+
+func (date Date) String() string {
+	return date.Time().Format(time.DateOnly)
+}
+
+func DateNow() Date {
+	return DateFrom(time.Now())
+}
+
+func DateFrom(tm time.Time) Date {
+	year, month, day := tm.Date()
+	return Date(time.Date(year, month, day, 0, 0, 0, 0, tm.Location()))
+}
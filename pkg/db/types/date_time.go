@@ -10,9 +10,9 @@ import (
 type DateTime time.Time
 
 func (dateTime *DateTime) Scan(v any) error {
-	newTime, ok := v.(time.Time)
-	if !ok {
-		return fmt.Errorf("不能将%T转换为DateTime", v)
+	newTime, err := scanTime(v, "DateTime", time.DateTime)
+	if err != nil {
+		return err
 	}
 	*dateTime = DateTime(newTime)
 	return nil
@@ -23,21 +23,41 @@ func (dateTime DateTime) Value() (driver.Value, error) {
 }
 
 func (dateTime DateTime) MarshalJSON() ([]byte, error) {
-	return json.Marshal(dateTime.Time().Format(time.DateTime))
+	return json.Marshal(dateTime.String())
 }
 
 func (dateTime *DateTime) UnmarshalJSON(data []byte) error {
 	var timeString string
-	err := json.Unmarshal(data, &timeString)
-	if err != nil {
-		return err
+	if err := json.Unmarshal(data, &timeString); err != nil {
+		var millis json.Number
+		if json.Unmarshal(data, &millis) != nil {
+			return err
+		}
+		timeString = millis.String()
 	}
-	newTime, err := time.ParseInLocation(time.DateTime, timeString, time.Local)
+	return dateTime.UnmarshalParam(timeString)
+}
+
+func (dateTime *DateTime) UnmarshalParam(param string) error {
+	newTime, err := GetDateTimeFormat().Parse(param)
 	if err != nil {
 		return err
 	}
 	*dateTime = DateTime(newTime)
-	return err
+	return nil
+}
+
+func (dateTime DateTime) GobEncode() ([]byte, error) {
+	return dateTime.Time().GobEncode()
+}
+
+func (dateTime *DateTime) GobDecode(data []byte) error {
+	var tm time.Time
+	if err := tm.GobDecode(data); err != nil {
+		return err
+	}
+	*dateTime = DateTime(tm)
+	return nil
 }
 
 func (dateTime DateTime) Time() time.Time {
@@ -45,7 +65,7 @@ func (dateTime DateTime) Time() time.Time {
 }
 
 func (dateTime DateTime) String() string {
-	return dateTime.Time().Format(time.DateTime)
+	return GetDateTimeFormat().Format(dateTime.Time())
 }
 
 func DateTimeNow() DateTime {
@@ -55,3 +75,28 @@ func DateTimeNow() DateTime {
 func DateTimeFrom(tm time.Time) DateTime {
 	return DateTime(tm)
 }
+
+func scanTime(v any, typeName string, layouts ...string) (time.Time, error) {
+	switch value := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return value, nil
+	case []byte:
+		return parseTime(string(value), typeName, layouts...)
+	case string:
+		return parseTime(value, typeName, layouts...)
+	}
+	return time.Time{}, fmt.Errorf("不能将%T转换为%s", v, typeName)
+}
+
+func parseTime(value string, typeName string, layouts ...string) (time.Time, error) {
+	layouts = append(layouts, time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999", time.DateOnly)
+	location := GetDateTimeFormat().location()
+	for _, layout := range layouts {
+		if tm, err := time.ParseInLocation(layout, value, location); err == nil {
+			return tm, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("不能将%s转换为%s", value, typeName)
+}
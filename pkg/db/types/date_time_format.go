@@ -0,0 +1,109 @@
+package types
+
+import (
+	"fmt"
+	"github.com/misakacoder/kagome/cond"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	formatMutex   sync.RWMutex
+	defaultFormat = DateTimeFormat{Layout: time.DateTime, Location: time.Local}
+	formats       = map[string]DateTimeFormat{}
+)
+
+type DateTimeFormat struct {
+	Layout   string
+	Location *time.Location
+}
+
+func (format DateTimeFormat) Format(tm time.Time) string {
+	return tm.In(format.location()).Format(format.layout())
+}
+
+func (format DateTimeFormat) Parse(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	location := format.location()
+	layouts := []string{format.layout(), time.RFC3339Nano, time.DateTime, time.DateOnly}
+	for _, layout := range layouts {
+		if tm, err := time.ParseInLocation(layout, value, location); err == nil {
+			return tm, nil
+		}
+	}
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(millis).In(location), nil
+	}
+	return time.Time{}, fmt.Errorf("不能将%s转换为DateTime", value)
+}
+
+func (format DateTimeFormat) layout() string {
+	if format.Layout == "" {
+		return GetDateTimeFormat().Layout
+	}
+	return format.Layout
+}
+
+func (format DateTimeFormat) location() *time.Location {
+	if format.Location == nil {
+		return GetDateTimeFormat().Location
+	}
+	return format.Location
+}
+
+func SetDateTimeFormat(layout string, zone string) error {
+	location := time.Local
+	if zone != "" {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return err
+		}
+		location = loc
+	}
+	formatMutex.Lock()
+	defer formatMutex.Unlock()
+	defaultFormat = DateTimeFormat{Layout: cond.Ternary(layout == "", time.DateTime, layout), Location: location}
+	return nil
+}
+
+func GetDateTimeFormat() DateTimeFormat {
+	formatMutex.RLock()
+	defer formatMutex.RUnlock()
+	return defaultFormat
+}
+
+func RegisterDateTimeFormat(name string, format DateTimeFormat) {
+	formatMutex.Lock()
+	defer formatMutex.Unlock()
+	formats[name] = format
+}
+
+func ParseDateTimeTag(tag string) (DateTimeFormat, error) {
+	if tag == "" {
+		return GetDateTimeFormat(), nil
+	}
+	formatMutex.RLock()
+	format, ok := formats[tag]
+	formatMutex.RUnlock()
+	if ok {
+		return format, nil
+	}
+	for _, option := range strings.Split(tag, ";") {
+		key, value, _ := strings.Cut(option, "=")
+		switch strings.TrimSpace(key) {
+		case "layout":
+			format.Layout = value
+		case "zone":
+			location, err := time.LoadLocation(strings.TrimSpace(value))
+			if err != nil {
+				return format, err
+			}
+			format.Location = location
+		default:
+			return format, fmt.Errorf("unknown datetime format %s", tag)
+		}
+	}
+	return format, nil
+}
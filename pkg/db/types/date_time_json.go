@@ -0,0 +1,287 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	jsonFieldCache   sync.Map
+	jsonScanCache    sync.Map
+	dateTimeType     = reflect.TypeOf(DateTime{})
+	nullDateTimeType = reflect.TypeOf(NullDateTime{})
+	marshalerType    = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	unmarshalerType  = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// DateTimeFieldError reports a value that does not match the format of the
+// DateTime field it is bound to.
+type DateTimeFieldError struct {
+	Field string
+	Value string
+}
+
+func (err *DateTimeFieldError) Error() string {
+	return fmt.Sprintf("%s的时间格式不正确", err.Field)
+}
+
+type jsonField struct {
+	name   string
+	tp     reflect.Type
+	format DateTimeFormat
+	tagged bool
+}
+
+type jsonFields struct {
+	fields []jsonField
+	err    error
+}
+
+type jsonScan struct {
+	tagged bool
+	err    error
+}
+
+// NormalizeJSON converts the DateTime and NullDateTime fields tagged with
+// `datetime:"..."` in a JSON document bound for objectType from their own
+// format to RFC 3339, which the default decoder always accepts. Documents that
+// are not valid JSON are returned as they are for the decoder to report.
+func NormalizeJSON(data []byte, objectType reflect.Type) ([]byte, error) {
+	scan := scanJSON(objectType)
+	if scan.err != nil || !scan.tagged {
+		return data, scan.err
+	}
+	tree, err := decodeJSON(data)
+	if err != nil {
+		return data, nil
+	}
+	if tree, err = parseJSON(objectType, tree); err != nil {
+		return nil, err
+	}
+	return json.Marshal(tree)
+}
+
+func parseJSON(tp reflect.Type, node any) (any, error) {
+	for tp.Kind() == reflect.Pointer {
+		tp = tp.Elem()
+	}
+	if !scanJSON(tp).tagged {
+		return node, nil
+	}
+	var err error
+	switch tp.Kind() {
+	case reflect.Struct:
+		object, ok := node.(map[string]any)
+		if !ok {
+			return node, nil
+		}
+		fields, _ := structJSONFields(tp)
+		for key, value := range object {
+			field, ok := matchJSONField(fields, key)
+			if !ok {
+				continue
+			}
+			if field.tagged {
+				object[key], err = parseDateTimes(field, value)
+			} else {
+				object[key], err = parseJSON(field.tp, value)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if array, ok := node.([]any); ok {
+			for i, element := range array {
+				if array[i], err = parseJSON(tp.Elem(), element); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case reflect.Map:
+		if object, ok := node.(map[string]any); ok {
+			for key, value := range object {
+				if object[key], err = parseJSON(tp.Elem(), value); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return node, nil
+}
+
+func parseDateTimes(field jsonField, node any) (any, error) {
+	var text string
+	switch value := node.(type) {
+	case []any:
+		for i, element := range value {
+			parsed, err := parseDateTimes(field, element)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = parsed
+		}
+		return value, nil
+	case string:
+		text = value
+	case json.Number:
+		text = value.String()
+	default:
+		return node, nil
+	}
+	if text == "" {
+		return node, nil
+	}
+	tm, err := field.format.Parse(text)
+	if err != nil {
+		return nil, &DateTimeFieldError{Field: field.name, Value: text}
+	}
+	return tm.Format(time.RFC3339Nano), nil
+}
+
+func matchJSONField(fields []jsonField, key string) (jsonField, bool) {
+	for _, field := range fields {
+		if field.name == key {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.name, key) {
+			return field, true
+		}
+	}
+	return jsonField{}, false
+}
+
+func scanJSON(tp reflect.Type) jsonScan {
+	if scan, ok := jsonScanCache.Load(tp); ok {
+		return scan.(jsonScan)
+	}
+	scan := scanJSONType(tp, map[reflect.Type]bool{})
+	jsonScanCache.Store(tp, scan)
+	return scan
+}
+
+func scanJSONType(tp reflect.Type, visiting map[reflect.Type]bool) jsonScan {
+	switch tp.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return scanJSONType(tp.Elem(), visiting)
+	case reflect.Struct:
+		if customJSON(tp) || visiting[tp] {
+			return jsonScan{}
+		}
+		visiting[tp] = true
+		fields, err := structJSONFields(tp)
+		scan := jsonScan{err: err}
+		for _, field := range fields {
+			if field.tagged {
+				scan.tagged = true
+				continue
+			}
+			fieldScan := scanJSONType(field.tp, visiting)
+			scan.tagged = scan.tagged || fieldScan.tagged
+			if scan.err == nil {
+				scan.err = fieldScan.err
+			}
+		}
+		return scan
+	}
+	return jsonScan{}
+}
+
+func structJSONFields(tp reflect.Type) ([]jsonField, error) {
+	if fields, ok := jsonFieldCache.Load(tp); ok {
+		result := fields.(jsonFields)
+		return result.fields, result.err
+	}
+	fields, err := collectJSONFields(tp, map[reflect.Type]bool{tp: true})
+	jsonFieldCache.Store(tp, jsonFields{fields: fields, err: err})
+	return fields, err
+}
+
+func collectJSONFields(tp reflect.Type, embedding map[reflect.Type]bool) ([]jsonField, error) {
+	var fields []jsonField
+	var promoted []jsonField
+	for i := 0; i < tp.NumField(); i++ {
+		structField := tp.Field(i)
+		tag := structField.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if structField.Anonymous && name == "" {
+			embedded := structField.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if !embedding[embedded] {
+					embedding[embedded] = true
+					embeddedFields, err := collectJSONFields(embedded, embedding)
+					if err != nil {
+						return nil, err
+					}
+					promoted = append(promoted, embeddedFields...)
+				}
+				continue
+			}
+		}
+		if !structField.IsExported() {
+			continue
+		}
+		field := jsonField{name: name, tp: structField.Type}
+		if field.name == "" {
+			field.name = structField.Name
+		}
+		if formatTag, ok := structField.Tag.Lookup("datetime"); ok && isDateTimeType(structField.Type) {
+			format, err := ParseDateTimeTag(formatTag)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", tp, structField.Name, err)
+			}
+			field.format = format
+			field.tagged = true
+		}
+		fields = append(fields, field)
+	}
+	for _, field := range promoted {
+		if !slices.ContainsFunc(fields, func(f jsonField) bool { return f.name == field.name }) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func isDateTimeType(tp reflect.Type) bool {
+	if tp.Kind() == reflect.Slice || tp.Kind() == reflect.Array {
+		tp = tp.Elem()
+	}
+	if tp.Kind() == reflect.Pointer {
+		tp = tp.Elem()
+	}
+	return tp == dateTimeType || tp == nullDateTimeType
+}
+
+func customJSON(tp reflect.Type) bool {
+	pointer := reflect.PointerTo(tp)
+	return tp.Implements(marshalerType) || pointer.Implements(marshalerType) || pointer.Implements(unmarshalerType)
+}
+
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var node any
+	if err := decoder.Decode(&node); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
+	}
+	return node, nil
+}
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type jsonAudit struct {
+	CreatedBy string   `json:"createdBy"`
+	CreatedAt DateTime `json:"createdAt" datetime:"layout=2006/01/02 15:04;zone=UTC"`
+}
+
+type jsonEvent struct {
+	Name     string         `json:"name"`
+	At       DateTime       `json:"at" datetime:"layout=2006-01-02T15:04:05Z07:00;zone=Asia/Tokyo"`
+	Day      *DateTime      `json:"day,omitempty" datetime:"layout=2006-01-02;zone=UTC"`
+	Closed   NullDateTime   `json:"closed" datetime:"layout=15:04;zone=UTC"`
+	Range    []DateTime     `json:"range" datetime:"layout=01-02;zone=UTC"`
+	Plain    DateTime       `json:"plain"`
+	Children []jsonEvent    `json:"children,omitempty"`
+	Extra    map[string]any `json:"extra,omitempty"`
+	jsonAudit
+}
+
+type jsonBadTag struct {
+	At DateTime `json:"at" datetime:"color=red"`
+}
+
+func TestNormalizeJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      string
+		wantField string
+	}{
+		{
+			name: "per field formats",
+			data: `{"name":"a","at":"2024-03-04T14:06:07+09:00","day":"2024-03-05","closed":"23:00","range":["03-04",1709683200000],"createdAt":"2024/03/04 05:06","children":[{"AT":"2024-03-06T08:00:00+09:00"}]}`,
+			want: `{"name":"a","at":"2024-03-04T14:06:07+09:00","day":"2024-03-05T00:00:00Z","closed":"0000-01-01T23:00:00Z","range":["0000-03-04T00:00:00Z","2024-03-06T00:00:00Z"],"createdAt":"2024-03-04T05:06:00Z","children":[{"AT":"2024-03-06T08:00:00+09:00"}]}`,
+		},
+		{
+			name: "nulls and empty strings",
+			data: `{"closed":null,"day":null,"range":[null],"at":""}`,
+			want: `{"closed":null,"day":null,"range":[null],"at":""}`,
+		},
+		{
+			name: "invalid json is left to the decoder",
+			data: `{"at":`,
+			want: `{"at":`,
+		},
+		{
+			name:      "wrong format",
+			data:      `{"children":[{"day":"03/05/2024"}]}`,
+			wantField: "day",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := NormalizeJSON([]byte(test.data), reflect.TypeOf(&jsonEvent{}))
+			if test.wantField != "" {
+				var fieldError *DateTimeFieldError
+				if !errors.As(err, &fieldError) || fieldError.Field != test.wantField {
+					t.Fatalf("err = %v, want field %s", err, test.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.want && !jsonEqual(data, []byte(test.want)) {
+				t.Fatalf("got  %s\nwant %s", data, test.want)
+			}
+		})
+	}
+}
+
+func jsonEqual(a []byte, b []byte) bool {
+	var left, right any
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func TestNormalizeJSONDecodes(t *testing.T) {
+	data, err := NormalizeJSON([]byte(`{"at":"2024-03-04T14:06:07+09:00","closed":"23:00","createdAt":"2024/03/04 05:06"}`), reflect.TypeOf(&jsonEvent{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event jsonEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatal(err)
+	}
+	if !event.At.Time().Equal(time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)) || !event.Closed.Valid || !event.CreatedAt.Time().Equal(time.Date(2024, 3, 4, 5, 6, 0, 0, time.UTC)) {
+		t.Fatalf("event = %+v", event)
+	}
+}
+
+func TestNormalizeJSONBadTag(t *testing.T) {
+	if _, err := NormalizeJSON([]byte(`{"at":"x"}`), reflect.TypeOf(jsonBadTag{})); err == nil {
+		t.Fatal("want error for unknown datetime tag option")
+	}
+}
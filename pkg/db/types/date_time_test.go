@@ -0,0 +1,86 @@
+package types
+
+import (
+	"gorm.io/gorm/schema"
+	"testing"
+	"time"
+)
+
+func TestDateTimeScanUsesConfiguredZone(t *testing.T) {
+	previous := GetDateTimeFormat()
+	t.Cleanup(func() {
+		formatMutex.Lock()
+		defaultFormat = previous
+		formatMutex.Unlock()
+	})
+	if err := SetDateTimeFormat("", "Asia/Shanghai"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{"2024-01-02 03:04:05", "2024-01-02T03:04:05+08:00"},
+		{[]byte("2024-01-02 03:04:05.5"), "2024-01-02T03:04:05.5+08:00"},
+		{"2024-01-02", "2024-01-02T00:00:00+08:00"},
+		{"2024-01-02T03:04:05Z", "2024-01-02T03:04:05Z"},
+		{"2024-01-02 03:04:05-05:00", "2024-01-02T03:04:05-05:00"},
+	}
+	for _, test := range tests {
+		var dateTime DateTime
+		if err := dateTime.Scan(test.value); err != nil {
+			t.Fatalf("Scan(%v): %v", test.value, err)
+		}
+		if got := dateTime.Time().Format(time.RFC3339Nano); got != test.want {
+			t.Errorf("Scan(%s) = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestNullDateTime(t *testing.T) {
+	if got := (NullDateTime{}).GormDataType(); got != string(schema.Time) {
+		t.Fatalf("GormDataType() = %s, want %s", got, schema.Time)
+	}
+	var nullDateTime NullDateTime
+	if err := nullDateTime.Scan(nil); err != nil || nullDateTime.Valid {
+		t.Fatalf("Scan(nil) = %+v, %v", nullDateTime, err)
+	}
+	if value, _ := nullDateTime.Value(); value != nil {
+		t.Fatalf("Value() = %v, want nil", value)
+	}
+	if data, _ := nullDateTime.MarshalJSON(); string(data) != "null" {
+		t.Fatalf("MarshalJSON() = %s", data)
+	}
+	if err := nullDateTime.UnmarshalJSON([]byte(`"2024-01-02 03:04:05"`)); err != nil || !nullDateTime.Valid {
+		t.Fatalf("UnmarshalJSON = %+v, %v", nullDateTime, err)
+	}
+}
+
+func TestDateUsesConfiguredZone(t *testing.T) {
+	previous := GetDateTimeFormat()
+	t.Cleanup(func() {
+		formatMutex.Lock()
+		defaultFormat = previous
+		formatMutex.Unlock()
+	})
+	if err := SetDateTimeFormat("", "Asia/Shanghai"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		bind func(date *Date) error
+	}{
+		{"param", func(date *Date) error { return date.UnmarshalParam("2024-01-02") }},
+		{"json", func(date *Date) error { return date.UnmarshalJSON([]byte(`"2024-01-02"`)) }},
+		{"scan", func(date *Date) error { return date.Scan("2024-01-02") }},
+	}
+	for _, test := range tests {
+		var date Date
+		if err := test.bind(&date); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := date.Time().Format(time.RFC3339); got != "2024-01-02T00:00:00+08:00" {
+			t.Errorf("%s = %s, want 2024-01-02T00:00:00+08:00", test.name, got)
+		}
+	}
+}
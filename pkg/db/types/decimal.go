@@ -0,0 +1,240 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalScale bounds the exponent and scale accepted by NewDecimal, so a
+// short input such as 1e999999999 cannot force a huge power of ten.
+const maxDecimalScale = 1000
+
+var ten = big.NewInt(10)
+
+type Decimal struct {
+	value *big.Int
+	scale int32
+}
+
+func (decimal *Decimal) Scan(v any) error {
+	switch value := v.(type) {
+	case nil:
+		*decimal = Decimal{}
+		return nil
+	case int64:
+		*decimal = DecimalFromInt(value)
+		return nil
+	case float64:
+		*decimal = DecimalFromFloat(value)
+		return nil
+	case []byte:
+		return decimal.UnmarshalParam(string(value))
+	case string:
+		return decimal.UnmarshalParam(value)
+	}
+	return fmt.Errorf("不能将%T转换为Decimal", v)
+}
+
+func (decimal Decimal) Value() (driver.Value, error) {
+	return decimal.String(), nil
+}
+
+func (Decimal) GormDataType() string {
+	return "decimal(38,10)"
+}
+
+func (decimal Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(decimal.String()), nil
+}
+
+func (decimal *Decimal) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		*decimal = Decimal{}
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	return decimal.UnmarshalParam(text)
+}
+
+func (decimal *Decimal) UnmarshalParam(param string) error {
+	newDecimal, err := NewDecimal(param)
+	if err != nil {
+		return err
+	}
+	*decimal = newDecimal
+	return nil
+}
+
+func (decimal Decimal) GobEncode() ([]byte, error) {
+	return []byte(decimal.String()), nil
+}
+
+func (decimal *Decimal) GobDecode(data []byte) error {
+	return decimal.UnmarshalParam(string(data))
+}
+
+func (decimal Decimal) Add(other Decimal) Decimal {
+	left, right, scale := align(decimal, other)
+	return Decimal{value: new(big.Int).Add(left, right), scale: scale}
+}
+
+func (decimal Decimal) Sub(other Decimal) Decimal {
+	left, right, scale := align(decimal, other)
+	return Decimal{value: new(big.Int).Sub(left, right), scale: scale}
+}
+
+func (decimal Decimal) Mul(other Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(decimal.unscaled(), other.unscaled()), scale: decimal.scale + other.scale}
+}
+
+func (decimal Decimal) Div(other Decimal, scale int32) Decimal {
+	if other.Sign() == 0 {
+		panic("decimal division by zero")
+	}
+	numerator := new(big.Int).Mul(decimal.unscaled(), pow10(scale+other.scale+1))
+	denominator := new(big.Int).Mul(other.unscaled(), pow10(decimal.scale))
+	quotient := Decimal{value: new(big.Int).Quo(numerator, denominator), scale: scale + 1}
+	return quotient.Round(scale)
+}
+
+func (decimal Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(decimal.unscaled()), scale: decimal.scale}
+}
+
+func (decimal Decimal) Round(scale int32) Decimal {
+	if scale >= decimal.scale {
+		return Decimal{value: new(big.Int).Mul(decimal.unscaled(), pow10(scale-decimal.scale)), scale: scale}
+	}
+	divisor := pow10(decimal.scale - scale)
+	quotient, remainder := new(big.Int).QuoRem(decimal.unscaled(), divisor, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(divisor) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(decimal.Sign())))
+	}
+	return Decimal{value: quotient, scale: scale}
+}
+
+func (decimal Decimal) Cmp(other Decimal) int {
+	left, right, _ := align(decimal, other)
+	return left.Cmp(right)
+}
+
+func (decimal Decimal) Equal(other Decimal) bool {
+	return decimal.Cmp(other) == 0
+}
+
+func (decimal Decimal) Sign() int {
+	return decimal.unscaled().Sign()
+}
+
+func (decimal Decimal) IsZero() bool {
+	return decimal.Sign() == 0
+}
+
+func (decimal Decimal) Scale() int32 {
+	return decimal.scale
+}
+
+func (decimal Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(decimal.unscaled(), pow10(decimal.scale))
+}
+
+func (decimal Decimal) Float64() float64 {
+	value, _ := strconv.ParseFloat(decimal.String(), 64)
+	return value
+}
+
+func (decimal Decimal) String() string {
+	digits := new(big.Int).Abs(decimal.unscaled()).String()
+	if decimal.scale > 0 {
+		if padding := int(decimal.scale) - len(digits) + 1; padding > 0 {
+			digits = strings.Repeat("0", padding) + digits
+		}
+		point := len(digits) - int(decimal.scale)
+		digits = digits[:point] + "." + digits[point:]
+	} else if decimal.scale < 0 && decimal.Sign() != 0 {
+		digits += strings.Repeat("0", int(-decimal.scale))
+	}
+	if decimal.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+func (decimal Decimal) unscaled() *big.Int {
+	if decimal.value == nil {
+		return new(big.Int)
+	}
+	return decimal.value
+}
+
+func NewDecimal(value string) (Decimal, error) {
+	text := strings.TrimSpace(value)
+	if text == "" {
+		return Decimal{}, nil
+	}
+	var exponent int64
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		parsed, err := strconv.ParseInt(text[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("不能将%s转换为Decimal", value)
+		}
+		if parsed < -maxDecimalScale || parsed > maxDecimalScale {
+			return Decimal{}, fmt.Errorf("%s超出Decimal的范围", value)
+		}
+		exponent = parsed
+		text = text[:i]
+	}
+	var scale int64
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		scale = int64(len(text) - i - 1)
+		text = text[:i] + text[i+1:]
+	}
+	unscaled, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("不能将%s转换为Decimal", value)
+	}
+	scale -= exponent
+	if scale < -maxDecimalScale || scale > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("%s超出Decimal的范围", value)
+	}
+	return Decimal{value: unscaled, scale: int32(scale)}, nil
+}
+
+func MustDecimal(value string) Decimal {
+	decimal, err := NewDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+	return decimal
+}
+
+func DecimalFromInt(value int64) Decimal {
+	return Decimal{value: big.NewInt(value)}
+}
+
+func DecimalFromFloat(value float64) Decimal {
+	return MustDecimal(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func align(left Decimal, right Decimal) (*big.Int, *big.Int, int32) {
+	if left.scale == right.scale {
+		return left.unscaled(), right.unscaled(), left.scale
+	}
+	if left.scale > right.scale {
+		return left.unscaled(), new(big.Int).Mul(right.unscaled(), pow10(left.scale-right.scale)), left.scale
+	}
+	return new(big.Int).Mul(left.unscaled(), pow10(right.scale-left.scale)), right.unscaled(), right.scale
+}
+
+func pow10(exponent int32) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(exponent)), nil)
+}
@@ -0,0 +1,145 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewDecimal(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		scale   int32
+		wantErr bool
+	}{
+		{"", "0", 0, false},
+		{"12", "12", 0, false},
+		{" -12.500 ", "-12.500", 3, false},
+		{"0.05", "0.05", 2, false},
+		{"-.5", "-0.5", 1, false},
+		{"1.5e3", "1500", -2, false},
+		{"1.5E-3", "0.0015", 4, false},
+		{"1e1000", "1" + strings.Repeat("0", 1000), -1000, false},
+		{"1e-1000", "0." + strings.Repeat("0", 999) + "1", 1000, false},
+		{"1e1001", "", 0, true},
+		{"1e-999999999", "", 0, true},
+		{"1e9999999999", "", 0, true},
+		{"0." + strings.Repeat("1", 1001), "", 0, true},
+		{"12.55e-999", "", 0, true},
+		{"abc", "", 0, true},
+		{"1.2.3", "", 0, true},
+		{"1e", "", 0, true},
+	}
+	for _, test := range tests {
+		decimal, err := NewDecimal(test.value)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("NewDecimal(%.20q) = %s, want error", test.value, decimal)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewDecimal(%q) error: %v", test.value, err)
+			continue
+		}
+		if got := decimal.String(); got != test.want || decimal.Scale() != test.scale {
+			t.Errorf("NewDecimal(%q) = %s (scale %d), want %s (scale %d)", test.value, got, decimal.Scale(), test.want, test.scale)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{"add", MustDecimal("1.1").Add(MustDecimal("2.25")), "3.35"},
+		{"sub", MustDecimal("1").Sub(MustDecimal("2.5")), "-1.5"},
+		{"mul", MustDecimal("1.5").Mul(MustDecimal("-0.2")), "-0.30"},
+		{"div", MustDecimal("1").Div(MustDecimal("3"), 4), "0.3333"},
+		{"div rounds", MustDecimal("2").Div(MustDecimal("3"), 2), "0.67"},
+		{"div negative", MustDecimal("-2").Div(MustDecimal("3"), 2), "-0.67"},
+		{"round half up", MustDecimal("2.345").Round(2), "2.35"},
+		{"round negative", MustDecimal("-2.345").Round(2), "-2.35"},
+		{"round extends", MustDecimal("2.5").Round(3), "2.500"},
+		{"neg", MustDecimal("2.5").Neg(), "-2.5"},
+		{"from int", DecimalFromInt(-7), "-7"},
+		{"from float", DecimalFromFloat(0.1), "0.1"},
+		{"zero value", Decimal{}, "0"},
+	}
+	for _, test := range tests {
+		if got := test.got.String(); got != test.want {
+			t.Errorf("%s = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDecimalCmp(t *testing.T) {
+	tests := []struct {
+		left  string
+		right string
+		want  int
+	}{
+		{"1.0", "1", 0},
+		{"1.01", "1.1", -1},
+		{"-1", "-2", 1},
+		{"1e2", "100.00", 0},
+	}
+	for _, test := range tests {
+		if got := MustDecimal(test.left).Cmp(MustDecimal(test.right)); got != test.want {
+			t.Errorf("Cmp(%s, %s) = %d, want %d", test.left, test.right, got, test.want)
+		}
+	}
+}
+
+func TestDecimalScanAndJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		scan    any
+		json    string
+		want    string
+		wantErr bool
+	}{
+		{name: "scan string", scan: "12.30", want: "12.30"},
+		{name: "scan bytes", scan: []byte("-0.5"), want: "-0.5"},
+		{name: "scan int64", scan: int64(42), want: "42"},
+		{name: "scan float64", scan: 1.25, want: "1.25"},
+		{name: "scan nil", scan: nil, want: "0"},
+		{name: "scan bool", scan: true, wantErr: true},
+		{name: "scan huge exponent", scan: "1e100000", wantErr: true},
+		{name: "json number", json: "12.30", want: "12.30"},
+		{name: "json string", json: `"-7.5"`, want: "-7.5"},
+		{name: "json null", json: "null", want: "0"},
+		{name: "json huge exponent", json: "9e99999999", wantErr: true},
+		{name: "json invalid", json: `"x"`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var decimal Decimal
+			var err error
+			if test.json != "" {
+				err = json.Unmarshal([]byte(test.json), &decimal)
+			} else {
+				err = decimal.Scan(test.scan)
+			}
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("decimal = %s, want error", decimal)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := decimal.String(); got != test.want {
+				t.Fatalf("decimal = %s, want %s", got, test.want)
+			}
+			data, _ := json.Marshal(decimal)
+			if string(data) != test.want {
+				t.Fatalf("json = %s, want %s", data, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package types
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrEncryptionKeyMissing = errors.New("encryption key is not configured")
+	ErrBlindIndexKeyMissing = errors.New("blind index key is not configured")
+	encryptionMutex         sync.RWMutex
+	encryptionKeys          keyring
+)
+
+type EncryptionConfig struct {
+	KeyID    string            `yaml:"keyId"`
+	Keys     map[string]string `yaml:"keys"`
+	IndexKey string            `yaml:"indexKey"`
+}
+
+type BlindIndexer interface {
+	BlindIndex() (string, error)
+}
+
+type keyring struct {
+	current  string
+	aeads    map[string]cipher.AEAD
+	indexKey []byte
+}
+
+func SetEncryption(config EncryptionConfig) error {
+	newKeyring := keyring{current: config.KeyID, aeads: map[string]cipher.AEAD{}}
+	for id, encoded := range config.Keys {
+		if id == "" || strings.Contains(id, ":") {
+			return fmt.Errorf("invalid encryption key id %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("encryption key %s: %w", id, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return fmt.Errorf("encryption key %s: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return err
+		}
+		newKeyring.aeads[id] = aead
+	}
+	if _, ok := newKeyring.aeads[config.KeyID]; !ok && len(newKeyring.aeads) > 0 {
+		return fmt.Errorf("encryption key %s is not in keys", config.KeyID)
+	}
+	if config.IndexKey != "" {
+		indexKey, err := base64.StdEncoding.DecodeString(config.IndexKey)
+		if err != nil {
+			return fmt.Errorf("blind index key: %w", err)
+		}
+		newKeyring.indexKey = indexKey
+	}
+	encryptionMutex.Lock()
+	defer encryptionMutex.Unlock()
+	encryptionKeys = newKeyring
+	return nil
+}
+
+func Encrypt(plaintext []byte) (string, error) {
+	encryptionMutex.RLock()
+	aead, ok := encryptionKeys.aeads[encryptionKeys.current]
+	keyID := encryptionKeys.current
+	encryptionMutex.RUnlock()
+	if !ok {
+		return "", ErrEncryptionKeyMissing
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(keyID))
+	return keyID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func Decrypt(ciphertext string) ([]byte, error) {
+	keyID, encoded, ok := strings.Cut(ciphertext, ":")
+	if !ok {
+		return nil, errors.New("ciphertext has no key id")
+	}
+	encryptionMutex.RLock()
+	aead, ok := encryptionKeys.aeads[keyID]
+	encryptionMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("encryption key %s is not configured", keyID)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, data := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, data, []byte(keyID))
+}
+
+func BlindIndexOf(plaintext []byte) (string, error) {
+	encryptionMutex.RLock()
+	indexKey := encryptionKeys.indexKey
+	encryptionMutex.RUnlock()
+	if len(indexKey) == 0 {
+		return "", ErrBlindIndexKeyMissing
+	}
+	mac := hmac.New(sha256.New, indexKey)
+	mac.Write(plaintext)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+type Encrypted[T any] struct {
+	Data T
+}
+
+func (encrypted *Encrypted[T]) Scan(v any) error {
+	var ciphertext string
+	switch value := v.(type) {
+	case nil:
+		*encrypted = Encrypted[T]{}
+		return nil
+	case []byte:
+		ciphertext = string(value)
+	case string:
+		ciphertext = value
+	default:
+		return fmt.Errorf("不能将%T转换为Encrypted", v)
+	}
+	if ciphertext == "" {
+		*encrypted = Encrypted[T]{}
+		return nil
+	}
+	plaintext, err := Decrypt(ciphertext)
+	if err != nil {
+		return err
+	}
+	return encrypted.decode(plaintext)
+}
+
+func (encrypted Encrypted[T]) Value() (driver.Value, error) {
+	plaintext, err := encrypted.encode()
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(plaintext)
+}
+
+func (Encrypted[T]) GormDataType() string {
+	return "string"
+}
+
+func (encrypted Encrypted[T]) BlindIndex() (string, error) {
+	plaintext, err := encrypted.encode()
+	if err != nil {
+		return "", err
+	}
+	return BlindIndexOf(plaintext)
+}
+
+func (encrypted Encrypted[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(encrypted.Data)
+}
+
+func (encrypted *Encrypted[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &encrypted.Data)
+}
+
+func (encrypted *Encrypted[T]) UnmarshalParam(param string) error {
+	return encrypted.decode([]byte(param))
+}
+
+func (encrypted Encrypted[T]) encode() ([]byte, error) {
+	if text, ok := any(encrypted.Data).(string); ok {
+		return []byte(text), nil
+	}
+	return json.Marshal(encrypted.Data)
+}
+
+func (encrypted *Encrypted[T]) decode(plaintext []byte) error {
+	if text, ok := any(&encrypted.Data).(*string); ok {
+		*text = string(plaintext)
+		return nil
+	}
+	var data T
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		return err
+	}
+	encrypted.Data = data
+	return nil
+}
+
+func EncryptedFrom[T any](data T) Encrypted[T] {
+	return Encrypted[T]{Data: data}
+}
@@ -0,0 +1,81 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type JSON[T any] struct {
+	Data T
+}
+
+func (jsonValue *JSON[T]) Scan(v any) error {
+	switch value := v.(type) {
+	case nil:
+		*jsonValue = JSON[T]{}
+		return nil
+	case []byte:
+		return jsonValue.unmarshal(value)
+	case string:
+		return jsonValue.unmarshal([]byte(value))
+	}
+	return fmt.Errorf("不能将%T转换为JSON", v)
+}
+
+func (jsonValue JSON[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(jsonValue.Data)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (JSON[T]) GormDataType() string {
+	return "json"
+}
+
+func (JSON[T]) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return jsonDBDataType(db)
+}
+
+func (jsonValue JSON[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonValue.Data)
+}
+
+func (jsonValue *JSON[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &jsonValue.Data)
+}
+
+func (jsonValue *JSON[T]) UnmarshalParam(param string) error {
+	return jsonValue.unmarshal([]byte(param))
+}
+
+func (jsonValue *JSON[T]) unmarshal(data []byte) error {
+	var result T
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &result); err != nil {
+			return err
+		}
+	}
+	jsonValue.Data = result
+	return nil
+}
+
+func JSONFrom[T any](data T) JSON[T] {
+	return JSON[T]{Data: data}
+}
+
+func jsonDBDataType(db *gorm.DB) string {
+	switch db.Dialector.Name() {
+	case "mysql":
+		return "json"
+	case "postgres":
+		return "jsonb"
+	case "sqlserver":
+		return "nvarchar(max)"
+	}
+	return "text"
+}
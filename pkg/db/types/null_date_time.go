@@ -0,0 +1,81 @@
+package types
+
+import (
+	"database/sql/driver"
+	"gorm.io/gorm/schema"
+	"time"
+)
+
+type NullDateTime struct {
+	DateTime DateTime
+	Valid    bool
+}
+
+func (nullDateTime *NullDateTime) Scan(v any) error {
+	if v == nil {
+		*nullDateTime = NullDateTime{}
+		return nil
+	}
+	if err := nullDateTime.DateTime.Scan(v); err != nil {
+		return err
+	}
+	nullDateTime.Valid = true
+	return nil
+}
+
+func (nullDateTime NullDateTime) Value() (driver.Value, error) {
+	if !nullDateTime.Valid {
+		return nil, nil
+	}
+	return nullDateTime.DateTime.Value()
+}
+
+func (nullDateTime NullDateTime) GormDataType() string {
+	return string(schema.Time)
+}
+
+func (nullDateTime NullDateTime) MarshalJSON() ([]byte, error) {
+	if !nullDateTime.Valid {
+		return []byte("null"), nil
+	}
+	return nullDateTime.DateTime.MarshalJSON()
+}
+
+func (nullDateTime *NullDateTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*nullDateTime = NullDateTime{}
+		return nil
+	}
+	if err := nullDateTime.DateTime.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	nullDateTime.Valid = true
+	return nil
+}
+
+func (nullDateTime *NullDateTime) UnmarshalParam(param string) error {
+	if param == "" {
+		*nullDateTime = NullDateTime{}
+		return nil
+	}
+	if err := nullDateTime.DateTime.UnmarshalParam(param); err != nil {
+		return err
+	}
+	nullDateTime.Valid = true
+	return nil
+}
+
+func (nullDateTime NullDateTime) Time() time.Time {
+	return nullDateTime.DateTime.Time()
+}
+
+func (nullDateTime NullDateTime) String() string {
+	if !nullDateTime.Valid {
+		return ""
+	}
+	return nullDateTime.DateTime.String()
+}
+
+func NullDateTimeFrom(tm time.Time) NullDateTime {
+	return NullDateTime{DateTime: DateTimeFrom(tm), Valid: true}
+}
@@ -0,0 +1,171 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"strconv"
+	"strings"
+)
+
+type StringSlice []string
+
+func (slice *StringSlice) Scan(v any) error {
+	values, err := scanSlice(v, "StringSlice")
+	if err != nil {
+		return err
+	}
+	*slice = values
+	return nil
+}
+
+func (slice StringSlice) Value() (driver.Value, error) {
+	return jsonSliceValue(slice)
+}
+
+func (StringSlice) GormDataType() string {
+	return "json"
+}
+
+func (StringSlice) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return jsonDBDataType(db)
+}
+
+type IntSlice []int64
+
+func (slice *IntSlice) Scan(v any) error {
+	values, err := scanSlice(v, "IntSlice")
+	if err != nil {
+		return err
+	}
+	ints, err := parseInts(values)
+	if err != nil {
+		return err
+	}
+	*slice = ints
+	return nil
+}
+
+func (slice IntSlice) Value() (driver.Value, error) {
+	return jsonSliceValue(slice)
+}
+
+func (IntSlice) GormDataType() string {
+	return "json"
+}
+
+func (IntSlice) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return jsonDBDataType(db)
+}
+
+type CommaStringSlice []string
+
+func (slice *CommaStringSlice) Scan(v any) error {
+	values, err := scanSlice(v, "CommaStringSlice")
+	if err != nil {
+		return err
+	}
+	*slice = values
+	return nil
+}
+
+func (slice CommaStringSlice) Value() (driver.Value, error) {
+	return strings.Join(slice, ","), nil
+}
+
+func (CommaStringSlice) GormDataType() string {
+	return "text"
+}
+
+type CommaIntSlice []int64
+
+func (slice *CommaIntSlice) Scan(v any) error {
+	values, err := scanSlice(v, "CommaIntSlice")
+	if err != nil {
+		return err
+	}
+	ints, err := parseInts(values)
+	if err != nil {
+		return err
+	}
+	*slice = ints
+	return nil
+}
+
+func (slice CommaIntSlice) Value() (driver.Value, error) {
+	values := make([]string, len(slice))
+	for i, value := range slice {
+		values[i] = strconv.FormatInt(value, 10)
+	}
+	return strings.Join(values, ","), nil
+}
+
+func (CommaIntSlice) GormDataType() string {
+	return "text"
+}
+
+func jsonSliceValue[T any](slice []T) (driver.Value, error) {
+	if slice == nil {
+		slice = []T{}
+	}
+	data, err := json.Marshal(slice)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func scanSlice(v any, typeName string) ([]string, error) {
+	var text string
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		text = string(value)
+	case string:
+		text = value
+	default:
+		return nil, fmt.Errorf("不能将%T转换为%s", v, typeName)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return []string{}, nil
+	}
+	if strings.HasPrefix(text, "[") {
+		var values []any
+		if err := json.Unmarshal([]byte(text), &values); err != nil {
+			return nil, err
+		}
+		result := make([]string, len(values))
+		for i, value := range values {
+			if str, ok := value.(string); ok {
+				result[i] = str
+			} else {
+				result[i] = fmt.Sprint(value)
+			}
+		}
+		return result, nil
+	}
+	values := strings.Split(text, ",")
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
+
+func parseInts(values []string) ([]int64, error) {
+	if values == nil {
+		return nil, nil
+	}
+	result := make([]int64, len(values))
+	for i, value := range values {
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = number
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Time time.Duration
+
+func (tm *Time) Scan(v any) error {
+	switch value := v.(type) {
+	case nil:
+		*tm = 0
+	case time.Time:
+		*tm = TimeFrom(value)
+	case int64:
+		*tm = Time(time.Duration(value) * time.Second)
+	case []byte:
+		return tm.UnmarshalParam(string(value))
+	case string:
+		return tm.UnmarshalParam(value)
+	default:
+		return fmt.Errorf("不能将%T转换为Time", v)
+	}
+	return nil
+}
+
+func (tm Time) Value() (driver.Value, error) {
+	return tm.String(), nil
+}
+
+func (tm Time) GormDataType() string {
+	return "time"
+}
+
+func (tm Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tm.String())
+}
+
+func (tm *Time) UnmarshalJSON(data []byte) error {
+	var timeString string
+	if err := json.Unmarshal(data, &timeString); err != nil {
+		return err
+	}
+	return tm.UnmarshalParam(timeString)
+}
+
+func (tm *Time) UnmarshalParam(param string) error {
+	if i := strings.IndexAny(param, "T "); i >= 0 {
+		param = param[i+1:]
+	}
+	negative := strings.HasPrefix(param, "-")
+	parts := strings.Split(strings.TrimPrefix(param, "-"), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("不能将%s转换为Time", param)
+	}
+	var duration time.Duration
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return fmt.Errorf("不能将%s转换为Time", param)
+		}
+		duration += time.Duration(value * float64(units[i]))
+	}
+	if negative {
+		duration = -duration
+	}
+	*tm = Time(duration)
+	return nil
+}
+
+func (tm Time) Duration() time.Duration {
+	return time.Duration(tm)
+}
+
+func (tm Time) String() string {
+	duration := tm.Duration()
+	sign := ""
+	if duration < 0 {
+		sign = "-"
+		duration = -duration
+	}
+	hour := duration / time.Hour
+	minute := duration % time.Hour / time.Minute
+	second := duration % time.Minute / time.Second
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, hour, minute, second)
+}
+
+func TimeNow() Time {
+	return TimeFrom(time.Now())
+}
+
+func TimeFrom(tm time.Time) Time {
+	hour, minute, second := tm.Clock()
+	return TimeOf(hour, minute, second)
+}
+
+func TimeOf(hour int, minute int, second int) Time {
+	return Time(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second)
+}
@@ -5,8 +5,21 @@ import (
 	"github.com/misakacoder/kagome/cond"
 	"gorm.io/gorm"
 	"math"
+	"regexp"
+	"strings"
 )
 
+var (
+	orderByPattern = regexp.MustCompile(`(?i)^[A-Za-z_][\w.]*(\s+(asc|desc))?$`)
+	orderByKeyword = regexp.MustCompile(`(?i)\border\s+by\b`)
+	limitKeyword   = regexp.MustCompile(`(?i)\b(limit|offset|fetch)\b`)
+)
+
+type clauses struct {
+	orderBy int
+	limit   int
+}
+
 type Page struct {
 	OrderBy  string `form:"orderBy"`
 	PageNum  int    `form:"pageNum"`
@@ -30,36 +43,126 @@ func PaginateResult[M, R any](db *gorm.DB, condition any, page *Page) PageResult
 	if !ok {
 		conditions = append(conditions, condition)
 	}
-	countDB := AddWhere(db.Model(model), conditions)
-	queryDB := AddWhere(db.Model(model), conditions)
-	return paginate[R](countDB, queryDB, page)
+	pageResult, _ := paginate[R](page, func(count *int64) error {
+		return AddWhere(db.Model(model), conditions).Count(count).Error
+	}, func(offset int, limit int, data *[]R) error {
+		return AddWhere(db.Model(model), conditions).Order(strings.Join(orderByFields(page.OrderBy), ", ")).Offset(offset).Limit(limit).Find(data).Error
+	})
+	return pageResult
 }
 
 func PaginateSQL[R any](db *gorm.DB, sql string, args []any, page *Page) PageResult[R] {
-	countDB := db.Raw(fmt.Sprintf("select count(1) from (%s) table_count", sql), args...)
-	queryDB := db.Raw(sql, args...)
-	return paginate[R](countDB, queryDB, page)
+	pageResult, _ := paginateSQL[R](db, sql, args, page, nil)
+	return pageResult
+}
+
+// PaginateBuilder pages a builder's query, compiled for the dialect of db.
+// An optional count builder replaces the generated count query. Unlike
+// PaginateSQL it reports compile and query errors to the caller.
+func PaginateBuilder[R any](db *gorm.DB, builder QueryBuilder, page *Page, count ...QueryBuilder) (PageResult[R], error) {
+	var countBuilder QueryBuilder
+	if len(count) > 0 {
+		countBuilder = count[0]
+	}
+	sqlBuilder, ok := builder.(*SQLBuilder)
+	if !ok || sqlBuilder.limit >= 0 || sqlBuilder.offset >= 0 {
+		sql, args, err := compileFor(db, builder)
+		if err != nil {
+			return PageResult[R]{PageNum: page.PageNum, List: []R{}}, err
+		}
+		return paginateSQL[R](db, sql, args, page, countBuilder)
+	}
+	return paginate[R](page, func(total *int64) error {
+		if countBuilder != nil {
+			return countBy(db, countBuilder, total)
+		}
+		clone := *sqlBuilder
+		clone.orderBy = nil
+		countSQL, countArgs, err := compileFor(db, &clone)
+		if err != nil {
+			return err
+		}
+		return db.Raw(fmt.Sprintf("select count(1) from (%s) table_count", countSQL), countArgs...).Scan(total).Error
+	}, func(offset int, limit int, data *[]R) error {
+		clone := *sqlBuilder
+		clone.orderBy = append(orderByFields(page.OrderBy), clone.orderBy...)
+		querySQL, queryArgs, err := compileFor(db, &clone)
+		if err != nil {
+			return err
+		}
+		return db.Raw(querySQL+DialectOf(db).LimitClause(limit, offset, len(clone.orderBy) > 0), queryArgs...).Scan(data).Error
+	})
+}
+
+func paginateSQL[R any](db *gorm.DB, sql string, args []any, page *Page, count QueryBuilder) (PageResult[R], error) {
+	dialect := DialectOf(db)
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+	clauses := parseClauses(sql)
+	return paginate[R](page, func(total *int64) error {
+		if count != nil {
+			return countBy(db, count, total)
+		}
+		countSQL := sql
+		if clauses.limit < 0 && clauses.orderBy >= 0 {
+			countSQL = strings.TrimSpace(sql[:clauses.orderBy])
+		}
+		return db.Raw(fmt.Sprintf("select count(1) from (%s) table_count", countSQL), args...).Scan(total).Error
+	}, func(offset int, limit int, data *[]R) error {
+		orderBy := strings.Join(orderByFields(page.OrderBy), ", ")
+		querySQL := sql
+		ordered := clauses.orderBy >= 0
+		switch {
+		case clauses.limit >= 0:
+			querySQL = fmt.Sprintf("select * from (%s) table_page", sql)
+			ordered = false
+			if orderBy != "" {
+				querySQL = fmt.Sprintf("%s order by %s", querySQL, orderBy)
+				ordered = true
+			}
+		case orderBy != "" && ordered:
+			location := orderByKeyword.FindStringIndex(sql[clauses.orderBy:])
+			querySQL = fmt.Sprintf("%s order by %s, %s", strings.TrimSpace(sql[:clauses.orderBy]), orderBy, strings.TrimSpace(sql[clauses.orderBy+location[1]:]))
+		case orderBy != "":
+			querySQL = fmt.Sprintf("%s order by %s", sql, orderBy)
+			ordered = true
+		}
+		return db.Raw(querySQL+dialect.LimitClause(limit, offset, ordered), args...).Scan(data).Error
+	})
+}
+
+func countBy(db *gorm.DB, count QueryBuilder, total *int64) error {
+	countSQL, countArgs, err := compileFor(db, count)
+	if err != nil {
+		return err
+	}
+	return db.Raw(countSQL, countArgs...).Scan(total).Error
 }
 
-func paginate[R any](countDB *gorm.DB, queryDB *gorm.DB, page *Page) PageResult[R] {
+func paginate[R any](page *Page, count func(total *int64) error, query func(offset int, limit int, data *[]R) error) (PageResult[R], error) {
 	rewritePage(page)
 	pageResult := PageResult[R]{
 		PageNum: page.PageNum,
 		List:    []R{},
 	}
-	var count int64
-	countDB.Count(&count)
-	pageResult.Total = int(count)
-	pages := int(math.Ceil(float64(count) / float64(page.PageSize)))
+	var total int64
+	if err := count(&total); err != nil {
+		return pageResult, err
+	}
+	pageResult.Total = int(total)
+	pages := int(math.Ceil(float64(total) / float64(page.PageSize)))
 	pageResult.Pages = pages
-	if count == 0 || pageResult.PageNum > pages {
-		return pageResult
+	if total == 0 || pageResult.PageNum > pages {
+		return pageResult, nil
 	}
 	var data []R
 	offset := (page.PageNum - 1) * page.PageSize
-	queryDB.Order(page.OrderBy).Offset(offset).Limit(page.PageSize).Find(&data)
-	pageResult.List = data
-	return pageResult
+	if err := query(offset, page.PageSize, &data); err != nil {
+		return pageResult, err
+	}
+	if data != nil {
+		pageResult.List = data
+	}
+	return pageResult, nil
 }
 
 func rewritePage(page *Page) {
@@ -70,3 +173,47 @@ func rewritePage(page *Page) {
 	page.PageNum = pageNum
 	page.PageSize = pageSize
 }
+
+func orderByFields(orderBy string) []string {
+	var fields []string
+	for _, field := range strings.Split(orderBy, ",") {
+		field = strings.TrimSpace(field)
+		if orderByPattern.MatchString(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func parseClauses(sql string) clauses {
+	masked := []byte(sql)
+	depth := 0
+	position := 0
+	scan(sql, func(text string, quoted bool) {
+		for i := 0; i < len(text); i++ {
+			char := text[i]
+			if quoted {
+				masked[position+i] = ' '
+				continue
+			}
+			if char == '(' {
+				depth++
+			}
+			if depth > 0 {
+				masked[position+i] = ' '
+			}
+			if char == ')' && depth > 0 {
+				depth--
+			}
+		}
+		position += len(text)
+	})
+	result := clauses{orderBy: -1, limit: -1}
+	if indexes := orderByKeyword.FindAllIndex(masked, -1); len(indexes) > 0 {
+		result.orderBy = indexes[len(indexes)-1][0]
+	}
+	if indexes := limitKeyword.FindAllIndex(masked, -1); len(indexes) > 0 {
+		result.limit = indexes[len(indexes)-1][0]
+	}
+	return result
+}
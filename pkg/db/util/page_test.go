@@ -0,0 +1,129 @@
+package util
+
+import (
+	"database/sql/driver"
+	"github.com/misakacoder/inuyasha/internal/dbtest"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type account struct {
+	ID   int64
+	Name string
+}
+
+func TestOrderByFields(t *testing.T) {
+	tests := []struct {
+		orderBy string
+		want    []string
+	}{
+		{"", nil},
+		{"id", []string{"id"}},
+		{"id desc, a.name ASC", []string{"id desc", "a.name ASC"}},
+		{"id; drop table account", nil},
+		{"name, (select 1)", []string{"name"}},
+		{"id desc nulls first", nil},
+		{"sleep(5)", nil},
+	}
+	for _, test := range tests {
+		if got := orderByFields(test.orderBy); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("orderByFields(%q) = %v, want %v", test.orderBy, got, test.want)
+		}
+	}
+}
+
+func TestParseClauses(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want clauses
+	}{
+		{"select * from t", clauses{orderBy: -1, limit: -1}},
+		{"select * from t order by id", clauses{orderBy: 16, limit: -1}},
+		{"select * from (select * from t order by id limit 1) x", clauses{orderBy: -1, limit: -1}},
+		{"select * from t where a = 'order by' limit 5", clauses{orderBy: -1, limit: 37}},
+	}
+	for _, test := range tests {
+		if got := parseClauses(test.sql); got != test.want {
+			t.Errorf("parseClauses(%q) = %+v, want %+v", test.sql, got, test.want)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialect   string
+		paginate  func(db *gorm.DB, page *Page) (PageResult[account], error)
+		page      Page
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			name:    "result drops unsafe order by",
+			dialect: "mysql",
+			paginate: func(db *gorm.DB, page *Page) (PageResult[account], error) {
+				return PaginateResult[account, account](db, []any{[]any{"name = ?", "a"}}, page), nil
+			},
+			page:      Page{OrderBy: "id desc, (case when 1=1 then sleep(5) end)", PageNum: 2, PageSize: 5},
+			wantQuery: "SELECT * FROM `accounts` WHERE name = ? ORDER BY id desc LIMIT ? OFFSET ?",
+			wantArgs:  []any{"a", int64(5), int64(5)},
+		},
+		{
+			name:    "sql keeps its order by",
+			dialect: "postgres",
+			paginate: func(db *gorm.DB, page *Page) (PageResult[account], error) {
+				return PaginateSQL[account](db, "select * from account where name = ? order by id;", []any{"a"}, page), nil
+			},
+			page:      Page{OrderBy: "name desc", PageNum: 1, PageSize: 5},
+			wantQuery: "select * from account where name = $1 order by name desc, id limit 5 offset 0",
+			wantArgs:  []any{"a"},
+		},
+		{
+			name:    "builder",
+			dialect: "postgres",
+			paginate: func(db *gorm.DB, page *Page) (PageResult[account], error) {
+				return PaginateBuilder[account](db, NewSelectBuilder("id", "name").From("account").Where("name in (?)", []any{[]string{"a", "b"}}, true), page)
+			},
+			page:      Page{OrderBy: "name; delete from account", PageNum: 1, PageSize: 5},
+			wantQuery: `select "id", "name" from "account" where name in ($1, $2) limit 5 offset 0`,
+			wantArgs:  []any{"a", "b"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, recorder := dbtest.Open(t, test.dialect)
+			recorder.Query = func(query string, args []any) dbtest.Result {
+				if strings.Contains(strings.ToLower(query), "count(") {
+					return dbtest.Result{Columns: []string{"count"}, Rows: [][]driver.Value{{int64(12)}}}
+				}
+				return dbtest.Result{Columns: []string{"id", "name"}, Rows: [][]driver.Value{{int64(1), "a"}}}
+			}
+			page := test.page
+			result, err := test.paginate(db, &page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Total != 12 || result.Pages != 3 || len(result.List) != 1 {
+				t.Fatalf("result = %+v", result)
+			}
+			statements := recorder.Statements()
+			last := statements[len(statements)-1]
+			if last.SQL != test.wantQuery || !reflect.DeepEqual(last.Args, test.wantArgs) {
+				t.Fatalf("query = %q %v, want %q %v", last.SQL, last.Args, test.wantQuery, test.wantArgs)
+			}
+		})
+	}
+}
+
+func TestPaginateBuilderError(t *testing.T) {
+	db, recorder := dbtest.Open(t, "mysql")
+	_, err := PaginateBuilder[account](db, NewSelectBuilder().From("account").Where("id = ? and name = ?", []any{1}, true), &Page{})
+	if err == nil || !strings.Contains(err.Error(), "missing argument") {
+		t.Fatalf("err = %v", err)
+	}
+	if len(recorder.Statements()) != 0 {
+		t.Fatalf("statements = %v", recorder.SQL())
+	}
+}
@@ -2,7 +2,7 @@ package util
 
 import (
 	"fmt"
-	"github.com/misakacoder/kagome/str"
+	"github.com/misakacoder/kagome/cond"
 	"strings"
 )
 
@@ -10,106 +10,342 @@ type QueryBuilder interface {
 	Build() (string, []any)
 }
 
+type statement int
+
+const (
+	rawStatement statement = iota
+	selectStatement
+	insertStatement
+	updateStatement
+	deleteStatement
+)
+
+type join struct {
+	kind  string
+	table string
+	on    string
+	args  []any
+}
+
+type assignment struct {
+	column string
+	sql    string
+	args   []any
+}
+
+type union struct {
+	all   bool
+	query QueryBuilder
+}
+
 type SQLBuilder struct {
-	sql     string
-	where   *str.Joiner
-	args    []any
-	orderBy string
+	statement statement
+	raw       string
+	rawArgs   []any
+	fields    []string
+	table     string
+	joins     []join
+	where     *Condition
+	groupBy   []string
+	having    *Condition
+	unions    []union
+	orderBy   []string
+	limit     int
+	offset    int
+	columns   []string
+	rows      [][]any
+	sets      []assignment
+	named     map[string]any
+	dialect   Dialect
+}
+
+func NewSQLBuilder(sql string, args ...any) *SQLBuilder {
+	builder := newSQLBuilder(rawStatement)
+	builder.raw = sql
+	builder.rawArgs = args
+	return builder
+}
+
+// Deprecated: use SQLBuilder.
+type SqlBuilder = SQLBuilder
+
+// Deprecated: use NewSelectBuilder.
+func NewSqlBuilder() *SQLBuilder {
+	return NewSelectBuilder()
+}
+
+func NewSelectBuilder(fields ...string) *SQLBuilder {
+	builder := newSQLBuilder(selectStatement)
+	builder.fields = append(builder.fields, fields...)
+	return builder
+}
+
+func NewInsertBuilder(table string, columns ...string) *SQLBuilder {
+	builder := newSQLBuilder(insertStatement)
+	builder.table = table
+	builder.columns = columns
+	return builder
+}
+
+func NewUpdateBuilder(table string) *SQLBuilder {
+	builder := newSQLBuilder(updateStatement)
+	builder.table = table
+	return builder
+}
+
+func NewDeleteBuilder(table string) *SQLBuilder {
+	builder := newSQLBuilder(deleteStatement)
+	builder.table = table
+	return builder
 }
 
-func NewSQLBuilder(sql string) *SQLBuilder {
+func newSQLBuilder(statement statement) *SQLBuilder {
 	return &SQLBuilder{
-		sql:   sql,
-		where: str.NewJoiner(" and ", "", ""),
+		statement: statement,
+		where:     NewCondition(),
+		having:    NewCondition(),
+		limit:     -1,
+		offset:    -1,
+		named:     map[string]any{},
 	}
 }
 
-func (builder *SQLBuilder) Where(sql string, arg []any, condition bool) {
-	if condition {
-		builder.where.Append(sql)
-		builder.args = append(builder.args, arg...)
-	}
+func (builder *SQLBuilder) Select(fields ...string) *SQLBuilder {
+	builder.fields = append(builder.fields, fields...)
+	return builder
 }
 
-func (builder *SQLBuilder) OrderBy(orderBy string) {
-	builder.orderBy = orderBy
+func (builder *SQLBuilder) From(table string) *SQLBuilder {
+	builder.table = table
+	return builder
 }
 
-func (builder *SQLBuilder) Build() (string, []any) {
-	sqlBuilder := strings.Builder{}
-	sqlBuilder.WriteString(strings.ReplaceAll(builder.sql, "\n", ""))
-	orderBy := builder.orderBy
-	if builder.where.Size() > 0 {
-		sqlBuilder.WriteString(fmt.Sprintf(" where %s", builder.where.String()))
-	}
-	if orderBy != "" {
-		sqlBuilder.WriteString(fmt.Sprintf(" order by %s", orderBy))
-	}
-	return sqlBuilder.String(), builder.args
+func (builder *SQLBuilder) Join(table string, on string, args ...any) *SQLBuilder {
+	return builder.join("join", table, on, args)
 }
 
-type SqlBuilder struct {
-	fragments []string
-	args      []any
-	hasWhere  bool
+func (builder *SQLBuilder) LeftJoin(table string, on string, args ...any) *SQLBuilder {
+	return builder.join("left join", table, on, args)
 }
 
-func NewSqlBuilder() *SqlBuilder {
-	return &SqlBuilder{
-		fragments: []string{"select"},
-	}
+func (builder *SQLBuilder) RightJoin(table string, on string, args ...any) *SQLBuilder {
+	return builder.join("right join", table, on, args)
+}
+
+func (builder *SQLBuilder) Where(sql string, arg []any, condition bool) *SQLBuilder {
+	builder.where.And(sql, arg, condition)
+	return builder
 }
 
-func (builder *SqlBuilder) Select(field string) *SqlBuilder {
-	builder.fragments = append(builder.fragments, fmt.Sprintf("%s,", field))
+func (builder *SQLBuilder) Or(sql string, arg []any, condition bool) *SQLBuilder {
+	builder.where.Or(sql, arg, condition)
 	return builder
 }
 
-func (builder *SqlBuilder) From(table string) *SqlBuilder {
-	fragments := builder.fragments
-	fragments[len(fragments)-1] = strings.TrimSuffix(fragments[len(fragments)-1], ",")
-	return builder.addFragment(fmt.Sprintf("from %s", table))
+func (builder *SQLBuilder) AndGroup(fn func(group *Condition)) *SQLBuilder {
+	builder.where.AndGroup(fn)
+	return builder
 }
 
-func (builder *SqlBuilder) Join(table string, on string) *SqlBuilder {
-	return builder.addFragment(fmt.Sprintf("join %s on %s", table, on))
+func (builder *SQLBuilder) OrGroup(fn func(group *Condition)) *SQLBuilder {
+	builder.where.OrGroup(fn)
+	return builder
 }
 
-func (builder *SqlBuilder) LeftJoin(table string, on string) *SqlBuilder {
-	return builder.addFragment(fmt.Sprintf("left join %s on %s", table, on))
+func (builder *SQLBuilder) GroupBy(fields ...string) *SQLBuilder {
+	builder.groupBy = append(builder.groupBy, fields...)
+	return builder
 }
 
-func (builder *SqlBuilder) RightJoin(table string, on string) *SqlBuilder {
-	return builder.addFragment(fmt.Sprintf("right join %s on %s", table, on))
+func (builder *SQLBuilder) Having(sql string, arg []any, condition bool) *SQLBuilder {
+	builder.having.And(sql, arg, condition)
+	return builder
+}
+
+func (builder *SQLBuilder) Union(query QueryBuilder) *SQLBuilder {
+	builder.unions = append(builder.unions, union{query: query})
+	return builder
 }
 
-func (builder *SqlBuilder) Where(sql string, arg []any, condition bool) *SqlBuilder {
-	if condition {
-		fragment := fmt.Sprintf("and %s", sql)
-		if !builder.hasWhere {
-			builder.addFragment("where")
-			fragment = sql
-			builder.hasWhere = true
+func (builder *SQLBuilder) UnionAll(query QueryBuilder) *SQLBuilder {
+	builder.unions = append(builder.unions, union{all: true, query: query})
+	return builder
+}
+
+func (builder *SQLBuilder) OrderBy(fields ...string) *SQLBuilder {
+	for _, field := range fields {
+		if strings.TrimSpace(field) != "" {
+			builder.orderBy = append(builder.orderBy, field)
 		}
-		builder.addFragment(fragment)
-		builder.args = append(builder.args, arg...)
 	}
 	return builder
 }
 
-func (builder *SqlBuilder) GroupBy(field string) *SqlBuilder {
-	return builder.addFragment(fmt.Sprintf("group by %s", field))
+func (builder *SQLBuilder) Limit(limit int) *SQLBuilder {
+	builder.limit = limit
+	return builder
 }
 
-func (builder *SqlBuilder) OrderBy(field string) *SqlBuilder {
-	return builder.addFragment(fmt.Sprintf("order by %s", field))
+func (builder *SQLBuilder) Offset(offset int) *SQLBuilder {
+	builder.offset = offset
+	return builder
 }
 
-func (builder *SqlBuilder) Build() (string, []any) {
-	return strings.Join(builder.fragments, " "), builder.args
+func (builder *SQLBuilder) Columns(columns ...string) *SQLBuilder {
+	builder.columns = append(builder.columns, columns...)
+	return builder
 }
 
-func (builder *SqlBuilder) addFragment(fragment string) *SqlBuilder {
-	builder.fragments = append(builder.fragments, fragment)
+func (builder *SQLBuilder) Values(values ...any) *SQLBuilder {
+	builder.rows = append(builder.rows, values)
 	return builder
 }
+
+func (builder *SQLBuilder) Set(column string, value any) *SQLBuilder {
+	builder.sets = append(builder.sets, assignment{column: column, sql: "?", args: []any{value}})
+	return builder
+}
+
+func (builder *SQLBuilder) SetExpr(column string, sql string, args ...any) *SQLBuilder {
+	builder.sets = append(builder.sets, assignment{column: column, sql: sql, args: args})
+	return builder
+}
+
+func (builder *SQLBuilder) Named(name string, value any) *SQLBuilder {
+	builder.named[name] = value
+	return builder
+}
+
+func (builder *SQLBuilder) Dialect(dialect Dialect) *SQLBuilder {
+	builder.dialect = dialect
+	return builder
+}
+
+func (builder *SQLBuilder) Build() (string, []any) {
+	sql, args, err := builder.Compile()
+	if err != nil {
+		panic(err)
+	}
+	return sql, args
+}
+
+// Compile is Build returning an error, such as a missing argument or an
+// insert without values, instead of panicking.
+func (builder *SQLBuilder) Compile() (string, []any, error) {
+	sql, args, err := builder.compile()
+	if err != nil {
+		return "", nil, err
+	}
+	return builder.dialect.Rebind(sql), args, nil
+}
+
+func (builder *SQLBuilder) compile() (string, []any, error) {
+	if builder.statement == insertStatement {
+		return builder.compileInsert()
+	}
+	sqlBuilder := &strings.Builder{}
+	var args []any
+	dialect := builder.dialect
+	switch builder.statement {
+	case rawStatement:
+		sqlBuilder.WriteString(strings.ReplaceAll(builder.raw, "\n", ""))
+		args = append(args, builder.rawArgs...)
+	case selectStatement:
+		sqlBuilder.WriteString("select ")
+		sqlBuilder.WriteString(cond.Ternary(len(builder.fields) == 0, "*", builder.quoteAll(builder.fields)))
+		if builder.table != "" {
+			sqlBuilder.WriteString(fmt.Sprintf(" from %s", dialect.Quote(builder.table)))
+		}
+		for _, join := range builder.joins {
+			sqlBuilder.WriteString(fmt.Sprintf(" %s %s on %s", join.kind, dialect.Quote(join.table), join.on))
+			args = append(args, join.args...)
+		}
+	case updateStatement:
+		sets := make([]string, len(builder.sets))
+		for i, set := range builder.sets {
+			sets[i] = fmt.Sprintf("%s = %s", dialect.Quote(set.column), set.sql)
+			args = append(args, set.args...)
+		}
+		sqlBuilder.WriteString(fmt.Sprintf("update %s set %s", dialect.Quote(builder.table), strings.Join(sets, ", ")))
+	case deleteStatement:
+		sqlBuilder.WriteString(fmt.Sprintf("delete from %s", dialect.Quote(builder.table)))
+	}
+	if builder.where.Size() > 0 {
+		where, whereArgs := builder.where.Build()
+		sqlBuilder.WriteString(fmt.Sprintf(" where %s", where))
+		args = append(args, whereArgs...)
+	}
+	if builder.statement == rawStatement || builder.statement == selectStatement {
+		if len(builder.groupBy) > 0 {
+			sqlBuilder.WriteString(fmt.Sprintf(" group by %s", builder.quoteAll(builder.groupBy)))
+		}
+		if builder.having.Size() > 0 {
+			having, havingArgs := builder.having.Build()
+			sqlBuilder.WriteString(fmt.Sprintf(" having %s", having))
+			args = append(args, havingArgs...)
+		}
+		unionArgs, err := writeUnions(sqlBuilder, builder.unions)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, unionArgs...)
+		if len(builder.orderBy) > 0 {
+			sqlBuilder.WriteString(fmt.Sprintf(" order by %s", builder.quoteAll(builder.orderBy)))
+		}
+		sqlBuilder.WriteString(dialect.LimitClause(builder.limit, builder.offset, len(builder.orderBy) > 0))
+	}
+	return Compile(sqlBuilder.String(), args, builder.named)
+}
+
+func (builder *SQLBuilder) compileInsert() (string, []any, error) {
+	if len(builder.rows) == 0 {
+		return "", nil, fmt.Errorf("insert into %s has no values", builder.table)
+	}
+	var args []any
+	rows := make([]string, len(builder.rows))
+	for i, row := range builder.rows {
+		if len(builder.columns) > 0 && len(row) != len(builder.columns) {
+			return "", nil, fmt.Errorf("insert into %s expects %d values, got %d", builder.table, len(builder.columns), len(row))
+		}
+		placeholders := make([]string, len(row))
+		for j := range row {
+			placeholders[j] = "?"
+		}
+		rows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+		args = append(args, row...)
+	}
+	sqlBuilder := strings.Builder{}
+	sqlBuilder.WriteString(fmt.Sprintf("insert into %s", builder.dialect.Quote(builder.table)))
+	if len(builder.columns) > 0 {
+		sqlBuilder.WriteString(fmt.Sprintf(" (%s)", builder.quoteAll(builder.columns)))
+	}
+	sqlBuilder.WriteString(fmt.Sprintf(" values %s", strings.Join(rows, ", ")))
+	return Compile(sqlBuilder.String(), args, builder.named)
+}
+
+func (builder *SQLBuilder) join(kind string, table string, on string, args []any) *SQLBuilder {
+	builder.joins = append(builder.joins, join{kind: kind, table: table, on: on, args: args})
+	return builder
+}
+
+func (builder *SQLBuilder) quoteAll(identifiers []string) string {
+	quoted := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		quoted[i] = builder.dialect.Quote(identifier)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func writeUnions(sqlBuilder *strings.Builder, unions []union) ([]any, error) {
+	var args []any
+	for _, union := range unions {
+		sql, unionArgs, err := buildQuery(union.query)
+		if err != nil {
+			return nil, err
+		}
+		sqlBuilder.WriteString(cond.Ternary(union.all, " union all ", " union "))
+		sqlBuilder.WriteString(sql)
+		args = append(args, unionArgs...)
+	}
+	return args, nil
+}
@@ -0,0 +1,329 @@
+package util
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDialectQuote(t *testing.T) {
+	tests := []struct {
+		dialect    Dialect
+		identifier string
+		want       string
+	}{
+		{MySQL, "user_name", "`user_name`"},
+		{MySQL, "u.userName", "`u`.`userName`"},
+		{MySQL, "u.*", "`u`.*"},
+		{PostgreSQL, "userName", `"username"`},
+		{PostgreSQL, "public.Users", `"public"."users"`},
+		{Oracle, "user_name", `"USER_NAME"`},
+		{SQLServer, "dbo.users", "[dbo].[users]"},
+		{SQLite, "users", `"users"`},
+		{MySQL, "*", "*"},
+		{MySQL, "distinct id", "distinct id"},
+		{MySQL, "users u", "users u"},
+		{MySQL, "name desc", "name desc"},
+		{PostgreSQL, "count(1) as total", "count(1) as total"},
+		{PostgreSQL, `"Quoted"`, `"Quoted"`},
+		{MySQL, "", ""},
+	}
+	for _, test := range tests {
+		if got := test.dialect.Quote(test.identifier); got != test.want {
+			t.Errorf("Quote(%d, %q) = %q, want %q", test.dialect, test.identifier, got, test.want)
+		}
+	}
+}
+
+func TestDialectRebind(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		sql     string
+		want    string
+	}{
+		{MySQL, "select ? from t where a = ?", "select ? from t where a = ?"},
+		{PostgreSQL, "select ? from t where a = ?", "select $1 from t where a = $2"},
+		{PostgreSQL, "select '?' from t where a = ?", "select '?' from t where a = $1"},
+		{PostgreSQL, `select "a?" from t where a = ? and b = 'it''s ?'`, `select "a?" from t where a = $1 and b = 'it''s ?'`},
+	}
+	for _, test := range tests {
+		if got := test.dialect.Rebind(test.sql); got != test.want {
+			t.Errorf("Rebind(%d, %q) = %q, want %q", test.dialect, test.sql, got, test.want)
+		}
+	}
+}
+
+func TestDialectLimitClause(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		limit   int
+		offset  int
+		ordered bool
+		want    string
+	}{
+		{MySQL, -1, -1, false, ""},
+		{MySQL, 10, 20, false, " limit 10 offset 20"},
+		{MySQL, -1, 20, false, " limit 20, 18446744073709551615"},
+		{PostgreSQL, -1, 20, false, " offset 20"},
+		{SQLite, -1, 20, false, " limit -1 offset 20"},
+		{SQLServer, 10, 20, false, " order by (select null) offset 20 rows fetch next 10 rows only"},
+		{SQLServer, 10, -1, true, " offset 0 rows fetch next 10 rows only"},
+		{Oracle, -1, 5, false, " offset 5 rows"},
+	}
+	for _, test := range tests {
+		if got := test.dialect.LimitClause(test.limit, test.offset, test.ordered); got != test.want {
+			t.Errorf("LimitClause(%d, %d, %d, %v) = %q, want %q", test.dialect, test.limit, test.offset, test.ordered, got, test.want)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		args     []any
+		named    map[string]any
+		wantSQL  string
+		wantArgs []any
+		wantErr  string
+	}{
+		{
+			name:     "positional",
+			sql:      "a = ? and b = ?",
+			args:     []any{1, "x"},
+			wantSQL:  "a = ? and b = ?",
+			wantArgs: []any{1, "x"},
+		},
+		{
+			name:     "in expansion",
+			sql:      "id in (?)",
+			args:     []any{[]int{1, 2, 3}},
+			wantSQL:  "id in (?, ?, ?)",
+			wantArgs: []any{1, 2, 3},
+		},
+		{
+			name:    "empty in",
+			sql:     "id in (?)",
+			args:    []any{[]int{}},
+			wantSQL: "id in (null)",
+		},
+		{
+			name:     "bytes are not expanded",
+			sql:      "data = ?",
+			args:     []any{[]byte("ab")},
+			wantSQL:  "data = ?",
+			wantArgs: []any{[]byte("ab")},
+		},
+		{
+			name:     "named",
+			sql:      "status = :status and id in (:ids)",
+			named:    map[string]any{"status": "paid", "ids": []string{"a", "b"}},
+			wantSQL:  "status = ? and id in (?, ?)",
+			wantArgs: []any{"paid", "a", "b"},
+		},
+		{
+			name:     "sql.Named",
+			sql:      "status = :status",
+			args:     []any{sql.Named("status", "paid")},
+			wantSQL:  "status = ?",
+			wantArgs: []any{"paid"},
+		},
+		{
+			name:     "casts and literals are untouched",
+			sql:      "a = ?::int and b = ':name?' and c = \"x?\"",
+			args:     []any{1},
+			wantSQL:  "a = ?::int and b = ':name?' and c = \"x?\"",
+			wantArgs: []any{1},
+		},
+		{
+			name:     "subquery",
+			sql:      "id in ? or id = (?)",
+			args:     []any{NewSelectBuilder("id").From("a").Where("x = ?", []any{1}, true), NewSQLBuilder("select max(id) from b")},
+			wantSQL:  "id in (select `id` from `a` where x = ?) or id = (select max(id) from b)",
+			wantArgs: []any{1},
+		},
+		{
+			name:    "missing positional",
+			sql:     "a = ? and b = ?",
+			args:    []any{1},
+			wantErr: "missing argument for placeholder 2",
+		},
+		{
+			name:    "extra positional",
+			sql:     "a = ?",
+			args:    []any{1, 2},
+			wantErr: "expected 1 arguments, got 2",
+		},
+		{
+			name:    "missing named",
+			sql:     "a = :a",
+			wantErr: "missing named argument :a",
+		},
+		{
+			name:    "subquery error",
+			sql:     "id in (?)",
+			args:    []any{NewSQLBuilder("select id from a where x = ?")},
+			wantErr: "missing argument for placeholder 1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sql, args, err := Compile(test.sql, test.args, test.named)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("err = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != test.wantSQL || !reflect.DeepEqual(args, test.wantArgs) {
+				t.Fatalf("got %q %v, want %q %v", sql, args, test.wantSQL, test.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSQLBuilderBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *SQLBuilder
+		wantSQL  string
+		wantArgs []any
+		wantErr  string
+	}{
+		{
+			name: "select in any call order",
+			builder: NewSelectBuilder("u.id", "u.name").
+				OrderBy("u.id desc").
+				Where("u.status = ?", []any{1}, true).
+				Where("u.name like ?", []any{"%a%"}, false).
+				From("users u").
+				LeftJoin("orders o", "o.user_id = u.id and o.state = ?", 2).
+				Limit(10).
+				Offset(20),
+			wantSQL:  "select `u`.`id`, `u`.`name` from users u left join orders o on o.user_id = u.id and o.state = ? where u.status = ? order by u.id desc limit 10 offset 20",
+			wantArgs: []any{2, 1},
+		},
+		{
+			name: "groups, having and union",
+			builder: NewSelectBuilder("dept", "count(1) total").From("emp").
+				Where("active = ?", []any{true}, true).
+				OrGroup(func(group *Condition) {
+					group.And("a = ?", []any{1}, true).And("b = ?", []any{2}, true)
+				}).
+				GroupBy("dept").
+				Having("count(1) > ?", []any{3}, true).
+				UnionAll(NewSelectBuilder("dept", "0").From("archive")),
+			wantSQL:  "select `dept`, count(1) total from `emp` where active = ? or (a = ? and b = ?) group by `dept` having count(1) > ? union all select `dept`, 0 from `archive`",
+			wantArgs: []any{true, 1, 2, 3},
+		},
+		{
+			name:     "postgres folds and rebinds",
+			builder:  NewSelectBuilder("userName", "distinct id").From("Users").Where("id in (:ids)", nil, true).Named("ids", []int{1, 2}).Dialect(PostgreSQL),
+			wantSQL:  `select "username", distinct id from "users" where id in ($1, $2)`,
+			wantArgs: []any{1, 2},
+		},
+		{
+			name:     "raw",
+			builder:  NewSQLBuilder("select *\nfrom t", 1).Where("a = ?", nil, true).Dialect(PostgreSQL),
+			wantSQL:  "select *from t where a = $1",
+			wantArgs: []any{1},
+		},
+		{
+			name:     "insert",
+			builder:  NewInsertBuilder("users", "id", "name").Values(1, "a").Values(2, "b").Dialect(PostgreSQL),
+			wantSQL:  `insert into "users" ("id", "name") values ($1, $2), ($3, $4)`,
+			wantArgs: []any{1, "a", 2, "b"},
+		},
+		{
+			name:     "update",
+			builder:  NewUpdateBuilder("users").Set("name", "a").SetExpr("version", "version + ?", 1).Where("id = ?", []any{7}, true),
+			wantSQL:  "update `users` set `name` = ?, `version` = version + ? where id = ?",
+			wantArgs: []any{"a", 1, 7},
+		},
+		{
+			name:     "delete",
+			builder:  NewDeleteBuilder("users").Where("id = ?", []any{7}, true).Dialect(SQLServer),
+			wantSQL:  "delete from [users] where id = ?",
+			wantArgs: []any{7},
+		},
+		{
+			name:    "insert without values",
+			builder: NewInsertBuilder("users", "id"),
+			wantErr: "has no values",
+		},
+		{
+			name:    "insert with wrong arity",
+			builder: NewInsertBuilder("users", "id", "name").Values(1),
+			wantErr: "expects 2 values, got 1",
+		},
+		{
+			name:    "missing argument",
+			builder: NewSelectBuilder().From("users").Where("id = ? and name = ?", []any{1}, true),
+			wantErr: "missing argument for placeholder 2",
+		},
+		{
+			name:     "external query builder",
+			builder:  NewSelectBuilder("id").From("a").Where("id in ?", []any{rawQuery{"select id from b where x = ?", []any{1}}}, true).Union(rawQuery{"select id from c", nil}),
+			wantSQL:  "select `id` from `a` where id in (select id from b where x = ?) union select id from c",
+			wantArgs: []any{1},
+		},
+		{
+			name:    "failing union",
+			builder: NewSelectBuilder("id").From("a").Union(NewSQLBuilder("select id from b where x = :x")),
+			wantErr: "missing named argument :x",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sql, args, err := test.builder.Compile()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("err = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != test.wantSQL || !reflect.DeepEqual(args, test.wantArgs) {
+				t.Fatalf("got %q %v, want %q %v", sql, args, test.wantSQL, test.wantArgs)
+			}
+		})
+	}
+}
+
+type rawQuery struct {
+	sql  string
+	args []any
+}
+
+func (query rawQuery) Build() (string, []any) {
+	return query.sql, query.args
+}
+
+func TestBuildPanics(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *SQLBuilder
+		panics  bool
+	}{
+		{name: "valid", builder: NewSelectBuilder().From("users").Where("id = ?", []any{1}, true)},
+		{name: "missing argument", builder: NewSelectBuilder().From("users").Where("id = ?", nil, true), panics: true},
+		{name: "deprecated alias", builder: NewSqlBuilder().From("users").Limit(1)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != test.panics {
+					t.Fatalf("recover() = %v, want panic %v", r, test.panics)
+				}
+			}()
+			var query QueryBuilder = test.builder
+			query.Build()
+		})
+	}
+}
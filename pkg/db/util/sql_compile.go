@@ -0,0 +1,299 @@
+package util
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"gorm.io/gorm"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_]\w*(\.[A-Za-z_]\w*)*(\.\*)?$`)
+
+type compiler interface {
+	compile() (string, []any, error)
+}
+
+type Dialect int
+
+const (
+	MySQL Dialect = iota
+	PostgreSQL
+	SQLite
+	SQLServer
+	Oracle
+)
+
+func DialectOf(db *gorm.DB) Dialect {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return PostgreSQL
+	case "sqlite":
+		return SQLite
+	case "sqlserver":
+		return SQLServer
+	case "oracle":
+		return Oracle
+	default:
+		return MySQL
+	}
+}
+
+// Quote quotes a bare, optionally qualified identifier such as user.name and
+// returns anything else (aliases, sort directions, expressions) untouched.
+// Names are folded the way the dialect folds unquoted identifiers, so quoting
+// never changes which column or table they resolve to.
+func (dialect Dialect) Quote(identifier string) string {
+	if !identifierPattern.MatchString(identifier) {
+		return identifier
+	}
+	names := strings.Split(identifier, ".")
+	for i, name := range names {
+		if name == "*" {
+			continue
+		}
+		switch dialect {
+		case MySQL:
+			names[i] = "`" + name + "`"
+		case PostgreSQL:
+			names[i] = `"` + strings.ToLower(name) + `"`
+		case Oracle:
+			names[i] = `"` + strings.ToUpper(name) + `"`
+		case SQLServer:
+			names[i] = "[" + name + "]"
+		default:
+			names[i] = `"` + name + `"`
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+func (dialect Dialect) LimitClause(limit int, offset int, ordered bool) string {
+	if limit < 0 && offset < 0 {
+		return ""
+	}
+	switch dialect {
+	case SQLServer, Oracle:
+		builder := strings.Builder{}
+		if dialect == SQLServer && !ordered {
+			builder.WriteString(" order by (select null)")
+		}
+		builder.WriteString(fmt.Sprintf(" offset %d rows", max(offset, 0)))
+		if limit >= 0 {
+			builder.WriteString(fmt.Sprintf(" fetch next %d rows only", limit))
+		}
+		return builder.String()
+	case MySQL:
+		if limit < 0 {
+			return fmt.Sprintf(" limit %d, 18446744073709551615", offset)
+		}
+	}
+	builder := strings.Builder{}
+	if limit >= 0 {
+		builder.WriteString(fmt.Sprintf(" limit %d", limit))
+	} else if dialect == SQLite {
+		builder.WriteString(" limit -1")
+	}
+	if offset >= 0 {
+		builder.WriteString(fmt.Sprintf(" offset %d", offset))
+	}
+	return builder.String()
+}
+
+func (dialect Dialect) Rebind(sql string) string {
+	if dialect != PostgreSQL {
+		return sql
+	}
+	builder := strings.Builder{}
+	index := 0
+	scan(sql, func(text string, quoted bool) {
+		if quoted {
+			builder.WriteString(text)
+			return
+		}
+		for _, r := range text {
+			if r == '?' {
+				index++
+				builder.WriteString("$" + strconv.Itoa(index))
+			} else {
+				builder.WriteRune(r)
+			}
+		}
+	})
+	return builder.String()
+}
+
+func compileFor(db *gorm.DB, builder QueryBuilder) (string, []any, error) {
+	sqlBuilder, ok := builder.(*SQLBuilder)
+	if !ok {
+		return buildQuery(builder)
+	}
+	clone := *sqlBuilder
+	clone.dialect = DialectOf(db)
+	return clone.compile()
+}
+
+// buildQuery compiles nested builders without rebinding, so placeholders are
+// numbered once for the outer statement.
+func buildQuery(query QueryBuilder) (string, []any, error) {
+	if compiler, ok := query.(compiler); ok {
+		return compiler.compile()
+	}
+	sql, args := query.Build()
+	return sql, args, nil
+}
+
+func Compile(sqlString string, args []any, named map[string]any) (string, []any, error) {
+	var positional []any
+	for _, arg := range args {
+		if namedArg, ok := arg.(sql.NamedArg); ok {
+			if named == nil {
+				named = map[string]any{}
+			}
+			named[namedArg.Name] = namedArg.Value
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	builder := strings.Builder{}
+	var result []any
+	var err error
+	index := 0
+	write := func(value any, wrapped bool) {
+		text, values, e := expand(value, wrapped)
+		if e != nil && err == nil {
+			err = e
+		}
+		builder.WriteString(text)
+		result = append(result, values...)
+	}
+	scan(sqlString, func(text string, quoted bool) {
+		if quoted {
+			builder.WriteString(text)
+			return
+		}
+		for i := 0; i < len(text); i++ {
+			char := text[i]
+			switch {
+			case char == '?':
+				if index >= len(positional) {
+					if err == nil {
+						err = fmt.Errorf("missing argument for placeholder %d", index+1)
+					}
+					builder.WriteByte(char)
+				} else {
+					write(positional[index], wrapped(builder.String(), text[i+1:]))
+				}
+				index++
+			case char == ':' && i+1 < len(text) && text[i+1] == ':':
+				builder.WriteString("::")
+				i++
+			case char == ':' && i+1 < len(text) && isIdentifier(text[i+1]) && (i == 0 || !isIdentifier(text[i-1])):
+				end := i + 1
+				for end < len(text) && isIdentifier(text[end]) {
+					end++
+				}
+				name := text[i+1 : end]
+				value, ok := named[name]
+				if !ok {
+					if err == nil {
+						err = fmt.Errorf("missing named argument :%s", name)
+					}
+					builder.WriteString(text[i:end])
+				} else {
+					write(value, wrapped(builder.String(), text[end:]))
+				}
+				i = end - 1
+			default:
+				builder.WriteByte(char)
+			}
+		}
+	})
+	if err == nil && index < len(positional) {
+		err = fmt.Errorf("expected %d arguments, got %d", index, len(positional))
+	}
+	return builder.String(), result, err
+}
+
+func expand(value any, wrapped bool) (string, []any, error) {
+	var sql string
+	var args []any
+	var err error
+	switch v := value.(type) {
+	case QueryBuilder:
+		sql, args, err = buildQuery(v)
+	case driver.Valuer, []byte:
+		return "?", []any{value}, nil
+	default:
+		return expandSlice(value)
+	}
+	if !wrapped {
+		sql = "(" + sql + ")"
+	}
+	return sql, args, err
+}
+
+func expandSlice(value any) (string, []any, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "?", []any{value}, nil
+	}
+	if rv.Len() == 0 {
+		return "null", nil, nil
+	}
+	placeholders := make([]string, rv.Len())
+	values := make([]any, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		placeholders[i] = "?"
+		values[i] = rv.Index(i).Interface()
+	}
+	return strings.Join(placeholders, ", "), values, nil
+}
+
+func wrapped(before string, after string) bool {
+	before = strings.TrimRight(before, " \t\r\n")
+	after = strings.TrimLeft(after, " \t\r\n")
+	return strings.HasSuffix(before, "(") && strings.HasPrefix(after, ")")
+}
+
+func scan(sql string, fn func(text string, quoted bool)) {
+	start := 0
+	for i := 0; i < len(sql); i++ {
+		quote := sql[i]
+		if quote != '\'' && quote != '"' && quote != '`' {
+			continue
+		}
+		if i > start {
+			fn(sql[start:i], false)
+		}
+		end := i + 1
+		for end < len(sql) {
+			if sql[end] == '\\' && quote == '\'' {
+				end += 2
+				continue
+			}
+			if sql[end] == quote {
+				if end+1 < len(sql) && sql[end+1] == quote {
+					end += 2
+					continue
+				}
+				break
+			}
+			end++
+		}
+		end = min(end+1, len(sql))
+		fn(sql[i:end], true)
+		start = end
+		i = end - 1
+	}
+	if start < len(sql) {
+		fn(sql[start:], false)
+	}
+}
+
+func isIdentifier(char byte) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+}
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"strings"
+)
+
+type Condition struct {
+	predicates []predicate
+}
+
+type predicate struct {
+	operator string
+	sql      string
+	args     []any
+}
+
+func NewCondition() *Condition {
+	return &Condition{}
+}
+
+func (condition *Condition) And(sql string, arg []any, add bool) *Condition {
+	if add {
+		condition.predicates = append(condition.predicates, predicate{operator: "and", sql: sql, args: arg})
+	}
+	return condition
+}
+
+func (condition *Condition) Or(sql string, arg []any, add bool) *Condition {
+	if add {
+		condition.predicates = append(condition.predicates, predicate{operator: "or", sql: sql, args: arg})
+	}
+	return condition
+}
+
+func (condition *Condition) AndGroup(fn func(group *Condition)) *Condition {
+	return condition.group("and", fn)
+}
+
+func (condition *Condition) OrGroup(fn func(group *Condition)) *Condition {
+	return condition.group("or", fn)
+}
+
+func (condition *Condition) Size() int {
+	return len(condition.predicates)
+}
+
+func (condition *Condition) Build() (string, []any) {
+	sql := strings.Builder{}
+	var args []any
+	for i, predicate := range condition.predicates {
+		if i > 0 {
+			sql.WriteString(" ")
+			sql.WriteString(predicate.operator)
+			sql.WriteString(" ")
+		}
+		sql.WriteString(predicate.sql)
+		args = append(args, predicate.args...)
+	}
+	return sql.String(), args
+}
+
+func (condition *Condition) group(operator string, fn func(group *Condition)) *Condition {
+	group := NewCondition()
+	fn(group)
+	if group.Size() > 0 {
+		sql, args := group.Build()
+		if group.Size() > 1 {
+			sql = "(" + sql + ")"
+		}
+		condition.predicates = append(condition.predicates, predicate{operator: operator, sql: sql, args: args})
+	}
+	return condition
+}
@@ -0,0 +1,255 @@
+package enum
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"github.com/misakacoder/inuyasha/http/req"
+	"github.com/misakacoder/kagome/cond"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type Item[C comparable] struct {
+	Code  C      `json:"code"`
+	Label string `json:"label"`
+	Name  string `json:"-"`
+}
+
+type Definition[C comparable] interface {
+	Items() []Item[C]
+}
+
+type ObjectJSON interface {
+	ObjectJSON() bool
+}
+
+type Enum[C comparable, D Definition[C]] struct {
+	code C
+}
+
+func Of[C comparable, D Definition[C]](code C) Enum[C, D] {
+	return Enum[C, D]{code: code}
+}
+
+func Items[C comparable, D Definition[C]]() []Item[C] {
+	var definition D
+	return definition.Items()
+}
+
+func Parse[C comparable, D Definition[C]](code C) (Enum[C, D], error) {
+	enum := Of[C, D](code)
+	if !enum.Valid() {
+		return enum, fmt.Errorf("%v不是有效的枚举值", code)
+	}
+	return enum, nil
+}
+
+func ParseLabel[C comparable, D Definition[C]](label string) (Enum[C, D], error) {
+	for _, item := range Items[C, D]() {
+		if item.Label == label {
+			return Of[C, D](item.Code), nil
+		}
+	}
+	return Enum[C, D]{}, fmt.Errorf("%s不是有效的枚举值", label)
+}
+
+func (enum Enum[C, D]) Code() C {
+	return enum.code
+}
+
+func (enum Enum[C, D]) Label() string {
+	for _, item := range Items[C, D]() {
+		if item.Code == enum.code {
+			return item.Label
+		}
+	}
+	return ""
+}
+
+func (enum Enum[C, D]) Valid() bool {
+	for _, item := range Items[C, D]() {
+		if item.Code == enum.code {
+			return true
+		}
+	}
+	return false
+}
+
+func (enum Enum[C, D]) Values() []req.Enum {
+	items := Items[C, D]()
+	values := make([]req.Enum, len(items))
+	for i, item := range items {
+		values[i] = Of[C, D](item.Code)
+	}
+	return values
+}
+
+func (enum Enum[C, D]) String() string {
+	if label := enum.Label(); label != "" {
+		return label
+	}
+	return fmt.Sprint(enum.code)
+}
+
+func (enum *Enum[C, D]) Scan(v any) error {
+	switch value := v.(type) {
+	case nil:
+		*enum = Enum[C, D]{}
+		return nil
+	case []byte:
+		return enum.UnmarshalParam(string(value))
+	case string:
+		return enum.UnmarshalParam(value)
+	case int64:
+		return enum.UnmarshalParam(strconv.FormatInt(value, 10))
+	case float64:
+		return enum.UnmarshalParam(strconv.FormatFloat(value, 'f', -1, 64))
+	case bool:
+		return enum.UnmarshalParam(strconv.FormatBool(value))
+	}
+	return fmt.Errorf("不能将%T转换为%T", v, enum.code)
+}
+
+func (enum Enum[C, D]) Value() (driver.Value, error) {
+	code := reflect.ValueOf(enum.code)
+	switch code.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return code.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(code.Uint()), nil
+	case reflect.String:
+		return code.String(), nil
+	case reflect.Bool:
+		return code.Bool(), nil
+	}
+	return enum.code, nil
+}
+
+func (enum Enum[C, D]) MarshalJSON() ([]byte, error) {
+	var definition D
+	if object, ok := any(definition).(ObjectJSON); ok && object.ObjectJSON() {
+		return json.Marshal(Item[C]{Code: enum.code, Label: enum.Label()})
+	}
+	return json.Marshal(enum.code)
+}
+
+func (enum *Enum[C, D]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		*enum = Enum[C, D]{}
+		return nil
+	}
+	if bytes.HasPrefix(data, []byte("{")) {
+		var item Item[C]
+		if err := json.Unmarshal(data, &item); err != nil {
+			return err
+		}
+		enum.code = item.Code
+		return nil
+	}
+	if err := json.Unmarshal(data, &enum.code); err == nil {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	return enum.UnmarshalParam(text)
+}
+
+func (enum Enum[C, D]) GobEncode() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	if err := gob.NewEncoder(&buffer).Encode(enum.code); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (enum *Enum[C, D]) GobDecode(data []byte) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(&enum.code)
+}
+
+func (enum *Enum[C, D]) UnmarshalParam(param string) error {
+	param = strings.TrimSpace(param)
+	code := reflect.ValueOf(&enum.code).Elem()
+	switch code.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(param, 10, code.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s不是有效的枚举值", param)
+		}
+		code.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(param, 10, code.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s不是有效的枚举值", param)
+		}
+		code.SetUint(value)
+	case reflect.String:
+		code.SetString(param)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(param)
+		if err != nil {
+			return fmt.Errorf("%s不是有效的枚举值", param)
+		}
+		code.SetBool(value)
+	default:
+		return json.Unmarshal([]byte(param), &enum.code)
+	}
+	return nil
+}
+
+func (enum *Enum[C, D]) UnmarshalLabel(label string) error {
+	parsed, err := ParseLabel[C, D](strings.TrimSpace(label))
+	if err != nil {
+		return err
+	}
+	*enum = parsed
+	return nil
+}
+
+// Schema describes the enum the way swag describes Go enum constants, so it
+// can be used as a swagger or OpenAPI definition. Item.Name is used for
+// x-enum-varnames and defaults to the label.
+func (enum Enum[C, D]) Schema() map[string]any {
+	items := Items[C, D]()
+	codes := make([]any, len(items))
+	labels := make([]string, len(items))
+	names := make([]string, len(items))
+	comments := make(map[string]string, len(items))
+	for i, item := range items {
+		codes[i] = item.Code
+		labels[i] = item.Label
+		names[i] = cond.Ternary(item.Name == "", item.Label, item.Name)
+		comments[names[i]] = item.Label
+	}
+	schemaType := "string"
+	switch reflect.TypeFor[C]().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schemaType = "integer"
+	case reflect.Bool:
+		schemaType = "boolean"
+	}
+	return map[string]any{
+		"type":                schemaType,
+		"enum":                codes,
+		"description":         enum.Describe(),
+		"x-enum-comments":     comments,
+		"x-enum-descriptions": labels,
+		"x-enum-varnames":     names,
+	}
+}
+
+func (enum Enum[C, D]) Describe() string {
+	items := Items[C, D]()
+	descriptions := make([]string, len(items))
+	for i, item := range items {
+		descriptions[i] = fmt.Sprintf("%v=%s", item.Code, item.Label)
+	}
+	return strings.Join(descriptions, ", ")
+}
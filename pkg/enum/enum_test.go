@@ -0,0 +1,144 @@
+package enum
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type status struct{}
+
+func (status) Items() []Item[int] {
+	return []Item[int]{{Code: 1, Label: "待支付", Name: "Pending"}, {Code: 2, Label: "已支付", Name: "Paid"}}
+}
+
+type level struct{}
+
+func (level) Items() []Item[int8] {
+	return []Item[int8]{{Code: 1, Label: "low"}}
+}
+
+type color struct{}
+
+func (color) Items() []Item[string] {
+	return []Item[string]{{Code: "65", Label: "A"}, {Code: "red", Label: "红"}}
+}
+
+type flag struct{}
+
+func (flag) Items() []Item[bool] {
+	return []Item[bool]{{Code: true, Label: "是"}, {Code: false, Label: "否"}}
+}
+
+func TestEnumScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		scan    func(v any) (any, error)
+		value   any
+		want    any
+		wantErr bool
+	}{
+		{name: "int from int64", scan: scanInto[int, status], value: int64(2), want: 2},
+		{name: "int from bytes", scan: scanInto[int, status], value: []byte("1"), want: 1},
+		{name: "int from float64", scan: scanInto[int, status], value: float64(2), want: 2},
+		{name: "int from fractional float64", scan: scanInto[int, status], value: 1.5, wantErr: true},
+		{name: "int8 overflow", scan: scanInto[int8, level], value: int64(300), wantErr: true},
+		{name: "string from int64", scan: scanInto[string, color], value: int64(65), want: "65"},
+		{name: "string from string", scan: scanInto[string, color], value: "red", want: "red"},
+		{name: "string from bool", scan: scanInto[string, color], value: true, want: "true"},
+		{name: "bool from int64", scan: scanInto[bool, flag], value: int64(1), want: true},
+		{name: "bool from bool", scan: scanInto[bool, flag], value: false, want: false},
+		{name: "nil", scan: scanInto[int, status], value: nil, want: 0},
+		{name: "unsupported", scan: scanInto[int, status], value: struct{}{}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.scan(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("code = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Fatalf("code = %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func scanInto[C comparable, D Definition[C]](v any) (any, error) {
+	var enum Enum[C, D]
+	err := enum.Scan(v)
+	return enum.Code(), err
+}
+
+func TestEnumSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   map[string]any
+		wantType string
+		varnames []string
+	}{
+		{name: "named items", schema: Enum[int, status]{}.Schema(), wantType: "integer", varnames: []string{"Pending", "Paid"}},
+		{name: "label fallback", schema: Enum[string, color]{}.Schema(), wantType: "string", varnames: []string{"A", "红"}},
+		{name: "bool", schema: Enum[bool, flag]{}.Schema(), wantType: "boolean", varnames: []string{"是", "否"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.schema["type"] != test.wantType {
+				t.Fatalf("type = %v, want %s", test.schema["type"], test.wantType)
+			}
+			if !reflect.DeepEqual(test.schema["x-enum-varnames"], test.varnames) {
+				t.Fatalf("x-enum-varnames = %v, want %v", test.schema["x-enum-varnames"], test.varnames)
+			}
+		})
+	}
+}
+
+func TestMergeDefinitions(t *testing.T) {
+	Register[int, status]("enum.OrderStatus")
+	tests := []struct {
+		name     string
+		document string
+		path     []string
+		existing bool
+	}{
+		{name: "swagger 2", document: `{"swagger":"2.0","definitions":{"resp.Result":{"type":"object"}}}`, path: []string{"definitions"}},
+		{name: "swagger 2 without definitions", document: `{"swagger":"2.0"}`, path: []string{"definitions"}},
+		{name: "openapi 3", document: `{"openapi":"3.0.1","components":{}}`, path: []string{"components", "schemas"}},
+		{name: "existing definition wins", document: `{"swagger":"2.0","definitions":{"enum.OrderStatus":{"type":"string"}}}`, path: []string{"definitions"}, existing: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := MergeDefinitions([]byte(test.document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var document map[string]any
+			if err := json.Unmarshal(data, &document); err != nil {
+				t.Fatal(err)
+			}
+			node := document
+			for _, key := range test.path {
+				node = node[key].(map[string]any)
+			}
+			definition := node["enum.OrderStatus"].(map[string]any)
+			if test.existing {
+				if definition["type"] != "string" {
+					t.Fatalf("definition overwritten: %v", definition)
+				}
+				return
+			}
+			if definition["type"] != "integer" || !reflect.DeepEqual(definition["enum"], []any{float64(1), float64(2)}) {
+				t.Fatalf("definition = %v", definition)
+			}
+		})
+	}
+	if _, err := MergeDefinitions([]byte("{")); err == nil {
+		t.Fatal("want error for invalid document")
+	}
+}
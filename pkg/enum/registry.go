@@ -0,0 +1,38 @@
+package enum
+
+import (
+	"sync"
+)
+
+var (
+	mutex    sync.RWMutex
+	registry = map[string]Describer{}
+)
+
+type Describer interface {
+	Schema() map[string]any
+	Describe() string
+}
+
+func Register[C comparable, D Definition[C]](name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	registry[name] = Enum[C, D]{}
+}
+
+func Schemas() map[string]map[string]any {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	schemas := make(map[string]map[string]any, len(registry))
+	for name, describer := range registry {
+		schemas[name] = describer.Schema()
+	}
+	return schemas
+}
+
+func Lookup(name string) (Describer, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	describer, ok := registry[name]
+	return describer, ok
+}
@@ -0,0 +1,36 @@
+package enum
+
+import (
+	"encoding/json"
+)
+
+// MergeDefinitions adds every registered enum to a swagger 2.0 document under
+// definitions, or to an OpenAPI 3 document under components.schemas, so
+// annotations can reference them by their registered name. Definitions the
+// document already has are left untouched.
+func MergeDefinitions(document []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return nil, err
+	}
+	parent, key := doc, "definitions"
+	if _, ok := doc["openapi"]; ok {
+		components, _ := doc["components"].(map[string]any)
+		if components == nil {
+			components = map[string]any{}
+			doc["components"] = components
+		}
+		parent, key = components, "schemas"
+	}
+	definitions, _ := parent[key].(map[string]any)
+	if definitions == nil {
+		definitions = map[string]any{}
+		parent[key] = definitions
+	}
+	for name, schema := range Schemas() {
+		if _, ok := definitions[name]; !ok {
+			definitions[name] = schema
+		}
+	}
+	return json.Marshal(doc)
+}
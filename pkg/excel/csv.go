@@ -0,0 +1,291 @@
+package excel
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"github.com/misakacoder/kagome/cond"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	bom             = "\uFEFF"
+	formulaTriggers = "=+-@\t\r"
+)
+
+var (
+	decimalType      = reflect.TypeOf(types.Decimal{})
+	excelLayoutTerms = strings.NewReplacer("yyyy", "2006", "yy", "06", "dd", "02", "hh", "15", "ss", "05")
+)
+
+type CSVOption func(options *csvOptions)
+
+type csvOptions struct {
+	delimiter rune
+	bom       bool
+	formulas  bool
+}
+
+func Delimiter(delimiter rune) CSVOption {
+	return func(options *csvOptions) {
+		options.delimiter = delimiter
+	}
+}
+
+func WithBOM() CSVOption {
+	return func(options *csvOptions) {
+		options.bom = true
+	}
+}
+
+// AllowFormulas writes text cells as they are. By default text starting with
+// = + - @ tab or carriage return is prefixed with ' so spreadsheets do not
+// evaluate it as a formula, and readers strip that prefix again.
+func AllowFormulas() CSVOption {
+	return func(options *csvOptions) {
+		options.formulas = true
+	}
+}
+
+func WriteCSVFile[T any](data []T, filename string, options ...CSVOption) error {
+	return write(filename, func(f *os.File) error {
+		return WriteCSV(data, f, options...)
+	})
+}
+
+func WriteCSV[T any](data []T, writer io.Writer, options ...CSVOption) error {
+	index := 0
+	return writeCSV(writer, newCSVOptions(options), func() (T, error) {
+		var result T
+		if index >= len(data) {
+			return result, io.EOF
+		}
+		result = data[index]
+		index++
+		return result, nil
+	})
+}
+
+func StreamWriteCSVFile[T any](reader Reader[T], filename string, options ...CSVOption) error {
+	return write(filename, func(f *os.File) error {
+		return StreamWriteCSV(reader, f, options...)
+	})
+}
+
+func StreamWriteCSV[T any](reader Reader[T], writer io.Writer, options ...CSVOption) error {
+	return writeCSV(writer, newCSVOptions(options), reader.Read)
+}
+
+func WriteTSV[T any](data []T, writer io.Writer, options ...CSVOption) error {
+	return WriteCSV(data, writer, append([]CSVOption{Delimiter('\t')}, options...)...)
+}
+
+func StreamWriteTSV[T any](reader Reader[T], writer io.Writer, options ...CSVOption) error {
+	return StreamWriteCSV(reader, writer, append([]CSVOption{Delimiter('\t')}, options...)...)
+}
+
+func ReadCSVFile[T any](filename string, options ...CSVOption) ([]T, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCSV[T](f, options...)
+}
+
+func ReadCSV[T any](reader io.Reader, options ...CSVOption) ([]T, error) {
+	var result []T
+	err := StreamReadCSV(reader, func(row int, data T) error {
+		result = append(result, data)
+		return nil
+	}, options...)
+	return result, err
+}
+
+func StreamReadCSV[T any](reader io.Reader, fn func(row int, data T) error, options ...CSVOption) error {
+	csvOptions := newCSVOptions(options)
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = csvOptions.delimiter
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	first := true
+	return readRows(func() ([]string, error) {
+		record, err := csvReader.Read()
+		if err == nil && first {
+			first = false
+			if len(record) > 0 {
+				record[0] = strings.TrimPrefix(record[0], bom)
+			}
+		}
+		if err == nil && !csvOptions.formulas {
+			for i, cell := range record {
+				record[i] = unescapeFormula(cell)
+			}
+		}
+		return record, err
+	}, false, fn)
+}
+
+func ReadTSV[T any](reader io.Reader, options ...CSVOption) ([]T, error) {
+	return ReadCSV[T](reader, append([]CSVOption{Delimiter('\t')}, options...)...)
+}
+
+func StreamReadTSV[T any](reader io.Reader, fn func(row int, data T) error, options ...CSVOption) error {
+	return StreamReadCSV(reader, fn, append([]CSVOption{Delimiter('\t')}, options...)...)
+}
+
+func newCSVOptions(opts []CSVOption) *csvOptions {
+	options := &csvOptions{delimiter: ','}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+func writeCSV[T any](writer io.Writer, options *csvOptions, next func() (T, error)) error {
+	cols := columns[T]()
+	buffered := bufio.NewWriter(writer)
+	if options.bom {
+		if _, err := buffered.WriteString(bom); err != nil {
+			return err
+		}
+	}
+	csvWriter := csv.NewWriter(buffered)
+	csvWriter.Comma = options.delimiter
+	record := make([]string, len(cols))
+	for i, col := range cols {
+		record[i] = col.header
+	}
+	if err := csvWriter.Write(record); err != nil {
+		return err
+	}
+	for {
+		data, err := next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		value := reflect.ValueOf(data)
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+		for i, col := range cols {
+			record[i] = csvValue(col.field(value), col, options.formulas)
+		}
+		if err = csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+func csvValue(field reflect.Value, col column, formulas bool) string {
+	if !field.IsValid() {
+		return ""
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+	if field.Type() == decimalType && col.dict == nil {
+		decimal := field.Interface().(types.Decimal)
+		if scale, ok := formatScale(col.format); ok {
+			decimal = decimal.Round(scale)
+		}
+		return decimal.String()
+	}
+	switch value := cellValue(field, col).(type) {
+	case time.Time:
+		return value.Format(goLayout(col))
+	case float32:
+		return formatFloat(float64(value), col.format)
+	case float64:
+		return formatFloat(value, col.format)
+	default:
+		text := fmt.Sprint(value)
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Bool:
+			return text
+		}
+		return cond.Ternary(formulas, text, escapeFormula(text))
+	}
+}
+
+func escapeFormula(value string) string {
+	if value != "" && strings.IndexByte(formulaTriggers, value[0]) >= 0 {
+		return "'" + value
+	}
+	return value
+}
+
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.IndexByte(formulaTriggers, value[1]) >= 0 {
+		return value[1:]
+	}
+	return value
+}
+
+func formatFloat(value float64, format string) string {
+	if scale, ok := formatScale(format); ok {
+		return strconv.FormatFloat(value, 'f', int(scale), 64)
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatScale(format string) (int32, bool) {
+	if format == "" || !strings.ContainsAny(format, "0#") {
+		return 0, false
+	}
+	_, decimals, ok := strings.Cut(format, ".")
+	if !ok {
+		return 0, true
+	}
+	return int32(strings.Count(decimals, "0") + strings.Count(decimals, "#")), true
+}
+
+func goLayout(col column) string {
+	if col.format == "" {
+		switch {
+		case col.kind == dateType:
+			return time.DateOnly
+		default:
+			return types.GetDateTimeFormat().Layout
+		}
+	}
+	format := strings.ToLower(col.format)
+	var layout strings.Builder
+	for i := 0; i < len(format); {
+		if strings.HasPrefix(format[i:], "mm") {
+			before := strings.TrimRight(layout.String(), ": ")
+			after := strings.TrimLeft(format[i+2:], ": ")
+			if strings.HasSuffix(before, "hh") || strings.HasPrefix(after, "ss") {
+				layout.WriteString("04")
+			} else {
+				layout.WriteString("01")
+			}
+			i += 2
+			continue
+		}
+		layout.WriteByte(format[i])
+		i++
+	}
+	return excelLayoutTerms.Replace(layout.String())
+}
@@ -0,0 +1,82 @@
+package excel
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type csvCode string
+
+type csvRecord struct {
+	Name    string  `excel:"名称"`
+	Code    csvCode `excel:"编码"`
+	Balance int     `excel:"余额"`
+	Rate    float64 `excel:"比例"`
+}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"=1+1", "'=1+1"},
+		{"+SUM(A1)", "'+SUM(A1)"},
+		{"-2+3", "'-2+3"},
+		{"@cmd", "'@cmd"},
+		{"\tx", "'\tx"},
+		{"\rx", "'\rx"},
+		{"a=b", "a=b"},
+		{"'=quoted", "'=quoted"},
+	}
+	for _, test := range tests {
+		if got := escapeFormula(test.value); got != test.want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", test.value, got, test.want)
+		}
+		if test.value != "" && test.value[0] != '\'' {
+			if got := unescapeFormula(escapeFormula(test.value)); got != test.value {
+				t.Errorf("unescapeFormula(escapeFormula(%q)) = %q", test.value, got)
+			}
+		}
+	}
+}
+
+func TestWriteCSVFormulas(t *testing.T) {
+	records := []csvRecord{{Name: "=HYPERLINK(\"http://x\")", Code: "@SUM(1)", Balance: -5, Rate: -0.5}}
+	tests := []struct {
+		name    string
+		options []CSVOption
+		want    string
+	}{
+		{
+			name: "escaped by default",
+			want: "名称,编码,余额,比例\n\"'=HYPERLINK(\"\"http://x\"\")\",'@SUM(1),-5,-0.5\n",
+		},
+		{
+			name:    "allowed",
+			options: []CSVOption{AllowFormulas()},
+			want:    "名称,编码,余额,比例\n\"=HYPERLINK(\"\"http://x\"\")\",@SUM(1),-5,-0.5\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			if err := WriteCSV(records, buffer, test.options...); err != nil {
+				t.Fatal(err)
+			}
+			if got := buffer.String(); got != test.want {
+				t.Fatalf("got  %q\nwant %q", got, test.want)
+			}
+			got, err := ReadCSV[csvRecord](strings.NewReader(buffer.String()), test.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, records) {
+				t.Fatalf("read %+v, want %+v", got, records)
+			}
+		})
+	}
+}
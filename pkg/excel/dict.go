@@ -0,0 +1,80 @@
+package excel
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var translators sync.Map
+
+type Formatter interface {
+	FormatCell() any
+}
+
+type Translator interface {
+	Label(code string) (string, bool)
+	Code(label string) (string, bool)
+}
+
+type DictItem struct {
+	Code  string
+	Label string
+}
+
+type Dict []DictItem
+
+func (dict Dict) Label(code string) (string, bool) {
+	for _, item := range dict {
+		if item.Code == code {
+			return item.Label, true
+		}
+	}
+	return "", false
+}
+
+func (dict Dict) Code(label string) (string, bool) {
+	for _, item := range dict {
+		if item.Label == label {
+			return item.Code, true
+		}
+	}
+	return "", false
+}
+
+func (dict Dict) Items() []DictItem {
+	return dict
+}
+
+func ParseDict(value string) Dict {
+	var dict Dict
+	for _, pair := range strings.Split(value, ",") {
+		code, label, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		dict = append(dict, DictItem{Code: strings.TrimSpace(code), Label: strings.TrimSpace(label)})
+	}
+	return dict
+}
+
+func RegisterTranslator(name string, translator Translator) {
+	translators.Store(name, translator)
+}
+
+func RegisterDict(name string, dict string) {
+	RegisterTranslator(name, ParseDict(dict))
+}
+
+func lookupTranslator(tag string) Translator {
+	if tag == "" {
+		return nil
+	}
+	if strings.Contains(tag, "=") {
+		return ParseDict(tag)
+	}
+	if translator, ok := translators.Load(tag); ok {
+		return translator.(Translator)
+	}
+	panic(fmt.Sprintf("excel translator %s is not registered", tag))
+}
@@ -0,0 +1,77 @@
+package excel
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/http/resp"
+	"net/http"
+)
+
+const (
+	contentType    = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	csvContentType = "text/csv; charset=utf-8"
+	tsvContentType = "text/tab-separated-values; charset=utf-8"
+)
+
+func Download[T any](ctx *gin.Context, filename string, data []T, options ...Option) {
+	workbook := NewWorkbook()
+	defer workbook.Close()
+	if err := AddSheet(workbook, "", data, options...); err != nil {
+		panic(err)
+	}
+	DownloadWorkbook(ctx, filename, workbook)
+}
+
+func StreamDownload[T any](ctx *gin.Context, filename string, reader Reader[T], options ...Option) {
+	workbook := NewWorkbook()
+	defer workbook.Close()
+	if err := AddStreamSheet(workbook, "", reader, options...); err != nil {
+		panic(err)
+	}
+	DownloadWorkbook(ctx, filename, workbook)
+}
+
+func DownloadTemplate[T any](ctx *gin.Context, filename string, options ...Option) {
+	workbook, err := Template[T](options...)
+	if err != nil {
+		panic(err)
+	}
+	defer workbook.Close()
+	DownloadWorkbook(ctx, filename, workbook)
+}
+
+func DownloadWorkbook(ctx *gin.Context, filename string, workbook *Workbook) {
+	resp.Attachment(ctx, filename, contentType)
+	ctx.Status(http.StatusOK)
+	if err := workbook.Write(ctx.Writer); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+func DownloadCSV[T any](ctx *gin.Context, filename string, data []T, options ...CSVOption) {
+	resp.Attachment(ctx, filename, csvContentType)
+	ctx.Status(http.StatusOK)
+	if err := WriteCSV(data, ctx.Writer, options...); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+func StreamDownloadCSV[T any](ctx *gin.Context, filename string, reader Reader[T], options ...CSVOption) {
+	resp.Attachment(ctx, filename, csvContentType)
+	ctx.Status(http.StatusOK)
+	if err := StreamWriteCSV(reader, ctx.Writer, options...); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+func DownloadTSV[T any](ctx *gin.Context, filename string, data []T, options ...CSVOption) {
+	resp.Attachment(ctx, filename, tsvContentType)
+	ctx.Status(http.StatusOK)
+	if err := WriteTSV(data, ctx.Writer, options...); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+func Attachment(ctx *gin.Context, filename string, data []byte) {
+	resp.Attachment(ctx, filename, contentType)
+	ctx.Data(http.StatusOK, contentType, data)
+}
@@ -1,112 +1,124 @@
 package excel
 
 import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
 	"github.com/misakacoder/kagome/cond"
-	"github.com/xuri/excelize/v2"
 	"io"
+	"math"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType             = reflect.TypeOf(time.Time{})
+	dateType             = reflect.TypeOf(types.Date{})
+	nullDateTimeType     = reflect.TypeOf(types.NullDateTime{})
+	formatterType        = reflect.TypeOf((*Formatter)(nil)).Elem()
+	labelerType          = reflect.TypeOf((*Labeler)(nil)).Elem()
+	scannerType          = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	paramUnmarshalerType = reflect.TypeOf((*ParamUnmarshaler)(nil)).Elem()
+	jsonMarshalerType    = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
 )
 
 type Reader[T any] interface {
 	Read() (T, error)
 }
 
-type column struct {
-	index  int
-	header string
-	width  float64
-	align  string
+type Labeler interface {
+	Label() string
 }
 
-func WriteFile[T any](data []T, filename string) error {
-	return write(filename, func(f *os.File) error {
-		return Write(data, f)
-	})
+type column struct {
+	index     []int
+	name      string
+	header    string
+	order     int
+	width     float64
+	autoWidth bool
+	align     string
+	format    string
+	wrap      bool
+	kind      reflect.Type
+	required  bool
+	dict      Translator
+	hint      string
 }
 
-func Write[T any](data []T, writer io.Writer) error {
-	cols := columns[T]()
-	excel := excelize.NewFile()
-	defer excel.Close()
-	sheetName := excel.GetSheetName(0)
-	err := setHeader(excel, sheetName, cols, func(headers []any) error {
-		return excel.SetSheetRow(sheetName, "A1", &headers)
-	})
-	if err != nil {
-		return err
-	}
-	rowNum := 2
-	row := make([]any, len(cols))
-	for _, item := range data {
-		cell, _ := excelize.CoordinatesToCellName(1, rowNum)
-		valid, errs := setRow(item, cols, row, func(row []any) error {
-			return excel.SetSheetRow(sheetName, cell, &row)
-		})
-		if errs != nil {
-			return errs
+func (col column) field(value reflect.Value) reflect.Value {
+	for i, index := range col.index {
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}
+			}
+			value = value.Elem()
 		}
-		if valid {
-			rowNum++
+		value = value.Field(index)
+	}
+	return value
+}
+
+func (col column) settableField(value reflect.Value) reflect.Value {
+	for i, index := range col.index {
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
 		}
+		value = value.Field(index)
 	}
-	if err = excel.Write(writer); err != nil {
-		return err
+	return value
+}
+
+func (col column) numberFormat() string {
+	if col.format != "" {
+		return col.format
 	}
-	return nil
+	switch {
+	case col.kind == dateType:
+		return defaultDateFormat
+	case col.kind.ConvertibleTo(timeType) || col.kind == nullDateTimeType:
+		return defaultDateTimeFormat
+	}
+	return ""
 }
 
-func StreamWriteFile[T any](reader Reader[T], filename string) error {
+func WriteFile[T any](data []T, filename string, options ...Option) error {
 	return write(filename, func(f *os.File) error {
-		return StreamWrite(reader, f)
+		return Write(data, f, options...)
 	})
 }
 
-func StreamWrite[T any](reader Reader[T], writer io.Writer) error {
-	cols := columns[T]()
-	excel := excelize.NewFile()
-	defer excel.Close()
-	sheetName := excel.GetSheetName(0)
-	streamWriter, err := excel.NewStreamWriter(sheetName)
-	if err != nil {
+func Write[T any](data []T, writer io.Writer, options ...Option) error {
+	workbook := NewWorkbook()
+	defer workbook.Close()
+	if err := AddSheet(workbook, "", data, options...); err != nil {
 		return err
 	}
-	err = setHeader(excel, sheetName, cols, func(headers []any) error {
-		return streamWriter.SetRow("A1", headers)
+	return workbook.Write(writer)
+}
+
+func StreamWriteFile[T any](reader Reader[T], filename string, options ...Option) error {
+	return write(filename, func(f *os.File) error {
+		return StreamWrite(reader, f, options...)
 	})
-	if err != nil {
-		return err
-	}
-	rowNum := 2
-	row := make([]any, len(cols))
-	for {
-		data, err := reader.Read()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return err
-		}
-		cell, _ := excelize.CoordinatesToCellName(1, rowNum)
-		valid, errs := setRow(data, cols, row, func(row []any) error {
-			return streamWriter.SetRow(cell, row)
-		})
-		if errs != nil {
-			return errs
-		}
-		if valid {
-			rowNum++
-		}
-	}
-	if err = streamWriter.Flush(); err != nil {
-		return err
-	}
-	if err = excel.Write(writer); err != nil {
+}
+
+func StreamWrite[T any](reader Reader[T], writer io.Writer, options ...Option) error {
+	workbook := NewWorkbook()
+	defer workbook.Close()
+	if err := AddStreamSheet(workbook, "", reader, options...); err != nil {
 		return err
 	}
-	return nil
+	return workbook.Write(writer)
 }
 
 func write(filename string, writer func(f *os.File) error) error {
@@ -127,50 +139,82 @@ func columns[T any]() []column {
 	if tp.Kind() != reflect.Struct {
 		panic(fmt.Sprintf("type T must be a struct or pointer to struct, got %s", tp.Kind()))
 	}
+	cols := structColumns(tp, nil, "", "", math.MaxInt)
+	if len(cols) == 0 {
+		panic("no exportable column")
+	}
+	slices.SortStableFunc(cols, func(a, b column) int {
+		return cmp.Compare(a.order, b.order)
+	})
+	return cols
+}
+
+func structColumns(tp reflect.Type, index []int, name string, prefix string, order int) []column {
 	var cols []column
 	for i := 0; i < tp.NumField(); i++ {
 		field := tp.Field(i)
 		tag := field.Tag.Get("excel")
-		if tag != "" {
-			width := 15.0
-			if widthString := field.Tag.Get("width"); widthString != "" {
-				if w, err := strconv.ParseFloat(widthString, 64); err == nil {
-					width = w
-				}
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		fieldIndex := append(slices.Clone(index), i)
+		fieldName := cond.Ternary(name == "", field.Name, name+"."+field.Name)
+		fieldOrder := order
+		if o, err := strconv.Atoi(field.Tag.Get("order")); err == nil {
+			fieldOrder = o
+		}
+		kind := field.Type
+		if kind.Kind() == reflect.Ptr {
+			kind = kind.Elem()
+		}
+		if nestedStruct(kind) && (field.Anonymous || tag != "") {
+			nestedPrefix := prefix
+			if tag != "" {
+				nestedPrefix = prefix + tag + "."
 			}
-			align := field.Tag.Get("align")
-			align = cond.Ternary(align != "", align, "left")
-			cols = append(cols, column{index: i, header: tag, width: width, align: align})
+			cols = append(cols, structColumns(kind, fieldIndex, fieldName, nestedPrefix, fieldOrder)...)
+			continue
 		}
-	}
-	if len(cols) == 0 {
-		panic("no exportable column")
+		if tag == "" {
+			continue
+		}
+		width := 15.0
+		widthString := field.Tag.Get("width")
+		if w, err := strconv.ParseFloat(widthString, 64); err == nil {
+			width = w
+		}
+		align := field.Tag.Get("align")
+		align = cond.Ternary(align != "", align, "left")
+		cols = append(cols, column{
+			index:     fieldIndex,
+			name:      fieldName,
+			header:    prefix + tag,
+			order:     fieldOrder,
+			width:     width,
+			autoWidth: widthString == "auto",
+			align:     align,
+			format:    field.Tag.Get("format"),
+			wrap:      field.Tag.Get("wrap") == "true",
+			kind:      kind,
+			required:  slices.Contains(strings.Split(field.Tag.Get("binding"), ","), "required"),
+			dict:      lookupTranslator(field.Tag.Get("dict")),
+			hint:      field.Tag.Get("comment"),
+		})
 	}
 	return cols
 }
 
-func setHeader(excel *excelize.File, sheetName string, cols []column, setHeader func(headers []any) error) error {
-	headers := make([]any, len(cols))
-	for i, col := range cols {
-		headers[i] = col.header
-		colName, _ := excelize.ColumnNumberToName(i + 1)
-		if err := excel.SetColWidth(sheetName, colName, colName, col.width); err != nil {
-			return err
-		}
-		style, err := excel.NewStyle(&excelize.Style{
-			Alignment: &excelize.Alignment{
-				Horizontal: col.align,
-				Vertical:   "center",
-			},
-		})
-		if err != nil {
-			return err
-		}
-		if err = excel.SetColStyle(sheetName, colName, style); err != nil {
-			return err
+func nestedStruct(tp reflect.Type) bool {
+	if tp.Kind() != reflect.Struct || tp.ConvertibleTo(timeType) {
+		return false
+	}
+	ptr := reflect.PointerTo(tp)
+	for _, special := range []reflect.Type{formatterType, labelerType, scannerType, paramUnmarshalerType, jsonMarshalerType} {
+		if tp.Implements(special) || ptr.Implements(special) {
+			return false
 		}
 	}
-	return setHeader(headers)
+	return true
 }
 
 func setRow[T any](v T, cols []column, row []any, setRow func(row []any) error) (bool, error) {
@@ -182,16 +226,53 @@ func setRow[T any](v T, cols []column, row []any, setRow func(row []any) error)
 		value = value.Elem()
 	}
 	for i, col := range cols {
-		field := value.Field(col.index)
-		if field.Kind() == reflect.Ptr {
-			if field.IsNil() {
-				row[i] = ""
-			} else {
-				row[i] = field.Elem().Interface()
-			}
-		} else {
-			row[i] = field.Interface()
-		}
+		row[i] = cellValue(col.field(value), col)
 	}
 	return true, setRow(row)
 }
+
+func cellValue(field reflect.Value, col column) any {
+	if !field.IsValid() {
+		return ""
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+	value := field.Interface()
+	if formatter, ok := value.(Formatter); ok {
+		return formatter.FormatCell()
+	}
+	if col.dict != nil {
+		code := fmt.Sprint(value)
+		if label, ok := col.dict.Label(code); ok {
+			return label
+		}
+		return code
+	}
+	switch v := value.(type) {
+	case Labeler:
+		return v.Label()
+	case types.NullDateTime:
+		if !v.Valid {
+			return ""
+		}
+		return excelTimeValue(v.Time())
+	case interface{ Float64() float64 }:
+		return v.Float64()
+	}
+	if field.Type().ConvertibleTo(timeType) {
+		return excelTimeValue(field.Convert(timeType).Interface().(time.Time))
+	}
+	return value
+}
+
+func excelTimeValue(tm time.Time) any {
+	if tm.IsZero() {
+		return ""
+	}
+	tm = tm.In(types.GetDateTimeFormat().Location)
+	return time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour(), tm.Minute(), tm.Second(), tm.Nanosecond(), time.UTC)
+}
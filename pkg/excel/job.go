@@ -0,0 +1,262 @@
+package excel
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/http/resp"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"github.com/misakacoder/inuyasha/pkg/function"
+	"github.com/misakacoder/inuyasha/pkg/task"
+	"github.com/misakacoder/logger"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	JobPending JobStatus = "pending"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+var (
+	ErrJobNotFound = errors.New("导出任务不存在或已过期")
+	ErrJobNotReady = errors.New("导出任务尚未完成")
+
+	jobManager     *JobManager
+	jobManagerOnce sync.Once
+)
+
+type JobStatus string
+
+type Job struct {
+	ID         string             `json:"id"`
+	Filename   string             `json:"filename"`
+	Status     JobStatus          `json:"status"`
+	Error      string             `json:"error,omitempty"`
+	Size       int64              `json:"size"`
+	CreatedAt  types.DateTime     `json:"createdAt"`
+	FinishedAt types.NullDateTime `json:"finishedAt"`
+	ExpiresAt  types.NullDateTime `json:"expiresAt"`
+	path       string
+}
+
+type JobManager struct {
+	dir     string
+	ttl     time.Duration
+	workers chan struct{}
+	jobs    map[string]*Job
+	mutex   sync.RWMutex
+}
+
+func NewJobManager(dir string, ttl time.Duration, concurrency int) *JobManager {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	manager := &JobManager{
+		dir:     dir,
+		ttl:     ttl,
+		workers: make(chan struct{}, concurrency),
+		jobs:    map[string]*Job{},
+	}
+	task.Register(manager.cleanup, max(min(ttl/2, time.Minute), time.Second))
+	return manager
+}
+
+func Jobs() *JobManager {
+	jobManagerOnce.Do(func() {
+		if jobManager == nil {
+			jobManager = NewJobManager(filepath.Join(os.TempDir(), "inuyasha-export"), time.Hour, 2)
+		}
+	})
+	return jobManager
+}
+
+func SetJobManager(manager *JobManager) {
+	jobManagerOnce.Do(func() {})
+	jobManager = manager
+}
+
+func SubmitDownload[T any](filename string, reader Reader[T], options ...Option) *Job {
+	return Jobs().Submit(filename, func(writer io.Writer) error {
+		return StreamWrite(reader, writer, options...)
+	})
+}
+
+func (manager *JobManager) Submit(filename string, fn func(writer io.Writer) error) *Job {
+	job := &Job{
+		ID:        newJobID(),
+		Filename:  filename,
+		Status:    JobPending,
+		CreatedAt: types.DateTimeNow(),
+	}
+	job.path = filepath.Join(manager.dir, job.ID+filepath.Ext(filename))
+	manager.mutex.Lock()
+	manager.jobs[job.ID] = job
+	snapshot := *job
+	manager.mutex.Unlock()
+	function.Async(func() {
+		manager.workers <- struct{}{}
+		defer func() { <-manager.workers }()
+		manager.run(job, fn)
+	})
+	return &snapshot
+}
+
+func (manager *JobManager) Get(id string) (Job, bool) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	job, ok := manager.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func (manager *JobManager) Open(id string) (*os.File, Job, error) {
+	job, ok := manager.Get(id)
+	if !ok {
+		return nil, job, ErrJobNotFound
+	}
+	if job.Status != JobDone {
+		return nil, job, ErrJobNotReady
+	}
+	f, err := os.Open(job.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, job, ErrJobNotFound
+	}
+	return f, job, err
+}
+
+func (manager *JobManager) Remove(id string) {
+	manager.mutex.Lock()
+	job, ok := manager.jobs[id]
+	delete(manager.jobs, id)
+	manager.mutex.Unlock()
+	if ok {
+		_ = os.Remove(job.path)
+	}
+}
+
+func (manager *JobManager) Download(ctx *gin.Context, id string) {
+	f, job, err := manager.Open(id)
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		resp.NotFound(ctx)
+		return
+	case errors.Is(err, ErrJobNotReady):
+		resp.ParameterError.Msg(err.Error()).Write(ctx)
+		return
+	case err != nil:
+		panic(err)
+	}
+	defer f.Close()
+	resp.Attachment(ctx, job.Filename, jobContentType(job.Filename))
+	http.ServeContent(ctx.Writer, ctx.Request, "", job.FinishedAt.Time(), f)
+}
+
+func (manager *JobManager) run(job *Job, fn func(writer io.Writer) error) {
+	if !manager.update(job, func(job *Job) { job.Status = JobRunning }) {
+		return
+	}
+	size, err := manager.write(job.path, fn)
+	tracked := manager.update(job, func(job *Job) {
+		now := time.Now()
+		job.FinishedAt = types.NullDateTimeFrom(now)
+		job.ExpiresAt = types.NullDateTimeFrom(now.Add(manager.ttl))
+		if err != nil {
+			job.Status = JobFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = JobDone
+		job.Size = size
+	})
+	if err != nil || !tracked {
+		_ = os.Remove(job.path)
+	}
+	if err != nil {
+		logger.Error("export job %s failed: %s", job.ID, err.Error())
+	}
+}
+
+func (manager *JobManager) write(path string, fn func(writer io.Writer) error) (size int64, err error) {
+	if err = os.MkdirAll(manager.dir, os.ModePerm); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	if err = fn(f); err != nil {
+		return
+	}
+	size, err = f.Seek(0, io.SeekCurrent)
+	return
+}
+
+// update applies fn to job and reports whether the job is still tracked. A job
+// removed while pending or running is left alone so its file can be dropped.
+func (manager *JobManager) update(job *Job, fn func(job *Job)) bool {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if manager.jobs[job.ID] != job {
+		return false
+	}
+	fn(job)
+	return true
+}
+
+func (manager *JobManager) cleanup() {
+	now := time.Now()
+	var expired []string
+	manager.mutex.RLock()
+	for id, job := range manager.jobs {
+		if job.ExpiresAt.Valid && now.After(job.ExpiresAt.Time()) {
+			expired = append(expired, id)
+		}
+	}
+	manager.mutex.RUnlock()
+	for _, id := range expired {
+		manager.Remove(id)
+	}
+}
+
+func jobContentType(filename string) string {
+	switch filepath.Ext(filename) {
+	case ".xlsx":
+		return contentType
+	case ".csv":
+		return csvContentType
+	case ".tsv":
+		return tsvContentType
+	}
+	if tp := mime.TypeByExtension(filepath.Ext(filename)); tp != "" {
+		return tp
+	}
+	return "application/octet-stream"
+}
+
+func newJobID() string {
+	bytes := make([]byte, 16)
+	_, _ = rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
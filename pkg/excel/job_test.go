@@ -0,0 +1,67 @@
+package excel
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestJobManagerRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		remove     bool
+		err        error
+		wantStatus JobStatus
+		wantFile   bool
+	}{
+		{name: "done", wantStatus: JobDone, wantFile: true},
+		{name: "failed", err: errors.New("boom"), wantStatus: JobFailed},
+		{name: "removed while running", remove: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := NewJobManager(t.TempDir(), time.Hour, 1)
+			started := make(chan struct{})
+			release := make(chan struct{})
+			finished := make(chan struct{})
+			job := manager.Submit("report.csv", func(writer io.Writer) error {
+				close(started)
+				<-release
+				_, _ = io.WriteString(writer, "data")
+				return test.err
+			})
+			<-started
+			manager.mutex.RLock()
+			tracked := manager.jobs[job.ID]
+			manager.mutex.RUnlock()
+			path := tracked.path
+			if test.remove {
+				manager.Remove(job.ID)
+			}
+			go func() {
+				defer close(finished)
+				for {
+					if len(manager.workers) == 0 {
+						return
+					}
+					time.Sleep(time.Millisecond)
+				}
+			}()
+			close(release)
+			select {
+			case <-finished:
+			case <-time.After(5 * time.Second):
+				t.Fatal("job did not finish")
+			}
+			got, ok := manager.Get(job.ID)
+			if ok != !test.remove || (ok && got.Status != test.wantStatus) {
+				t.Fatalf("Get() = %+v, %v", got, ok)
+			}
+			if _, err := os.Stat(path); (err == nil) != test.wantFile {
+				t.Fatalf("file exists = %v, want %v", err == nil, test.wantFile)
+			}
+		})
+	}
+}
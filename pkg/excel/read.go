@@ -0,0 +1,398 @@
+package excel
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/misakacoder/inuyasha/http/resp"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LabelUnmarshaler interface {
+	UnmarshalLabel(label string) error
+}
+
+type ParamUnmarshaler interface {
+	UnmarshalParam(param string) error
+}
+
+type CellError struct {
+	Row     int    `json:"row"`
+	Column  int    `json:"column"`
+	Header  string `json:"header"`
+	Message string `json:"message"`
+}
+
+func (cellError CellError) Cell() string {
+	if cellError.Column <= 0 {
+		return ""
+	}
+	cell, _ := excelize.CoordinatesToCellName(cellError.Column, cellError.Row)
+	return cell
+}
+
+func (cellError CellError) Error() string {
+	if cell := cellError.Cell(); cell != "" {
+		return fmt.Sprintf("第%d行[%s]%s: %s", cellError.Row, cell, cellError.Header, cellError.Message)
+	}
+	return fmt.Sprintf("第%d行: %s", cellError.Row, cellError.Message)
+}
+
+type ReadError []CellError
+
+func (readError ReadError) Error() string {
+	messages := make([]string, len(readError))
+	for i, cellError := range readError {
+		messages[i] = cellError.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func ReadFile[T any](filename string) ([]T, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read[T](f)
+}
+
+func Read[T any](reader io.Reader) ([]T, error) {
+	var result []T
+	err := StreamRead(reader, func(row int, data T) error {
+		result = append(result, data)
+		return nil
+	})
+	return result, err
+}
+
+func StreamRead[T any](reader io.Reader, fn func(row int, data T) error) error {
+	excel, err := excelize.OpenReader(reader)
+	if err != nil {
+		return err
+	}
+	defer excel.Close()
+	rows, err := excel.Rows(excel.GetSheetName(0))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return readRows(func() ([]string, error) {
+		if !rows.Next() {
+			if err := rows.Error(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		return rows.Columns(excelize.Options{RawCellValue: true})
+	}, true, fn)
+}
+
+func readRows[T any](next func() ([]string, error), serial bool, fn func(row int, data T) error) error {
+	cols := columns[T]()
+	var readError ReadError
+	var positions []int
+	rowNum := 0
+	for {
+		cells, err := next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		rowNum++
+		if positions == nil {
+			positions, readError = headerPositions(cells, cols)
+			continue
+		}
+		if blankRow(cells) {
+			continue
+		}
+		data, cellErrors := readRow[T](rowNum, cells, cols, positions, serial)
+		if len(cellErrors) > 0 {
+			readError = append(readError, cellErrors...)
+			continue
+		}
+		if err = fn(rowNum, data); err != nil {
+			var cellError CellError
+			if errors.As(err, &cellError) {
+				readError = append(readError, cellError)
+				continue
+			}
+			return err
+		}
+	}
+	if len(readError) > 0 {
+		return readError
+	}
+	return nil
+}
+
+func headerPositions(cells []string, cols []column) ([]int, ReadError) {
+	headers := map[string]int{}
+	for i, cell := range cells {
+		if header := strings.TrimSpace(cell); header != "" {
+			if _, ok := headers[header]; !ok {
+				headers[header] = i
+			}
+		}
+	}
+	var readError ReadError
+	positions := make([]int, len(cols))
+	for i, col := range cols {
+		position, ok := headers[col.header]
+		if !ok {
+			position = -1
+			if col.required {
+				readError = append(readError, CellError{Row: 1, Header: col.header, Message: fmt.Sprintf("缺少列%s", col.header)})
+			}
+		}
+		positions[i] = position
+	}
+	return positions, readError
+}
+
+func readRow[T any](rowNum int, cells []string, cols []column, positions []int, serial bool) (T, []CellError) {
+	var result T
+	value := reflect.ValueOf(&result).Elem()
+	if value.Kind() == reflect.Ptr {
+		value.Set(reflect.New(value.Type().Elem()))
+		value = value.Elem()
+	}
+	var cellErrors []CellError
+	for i, col := range cols {
+		position := positions[i]
+		if position < 0 || position >= len(cells) {
+			continue
+		}
+		if err := setCell(col.settableField(value), formattedCell(col, cells[position]), serial); err != nil {
+			cellErrors = append(cellErrors, CellError{Row: rowNum, Column: position + 1, Header: col.header, Message: err.Error()})
+		}
+	}
+	if len(cellErrors) > 0 {
+		return result, cellErrors
+	}
+	for _, fieldError := range validateRow(value.Addr().Interface()) {
+		cellError := CellError{Row: rowNum, Message: fieldError.message}
+		for i, col := range cols {
+			if col.name == fieldError.field {
+				cellError.Header = col.header
+				if positions[i] >= 0 {
+					cellError.Column = positions[i] + 1
+				}
+				cellError.Message = validationMessage(col.header, fieldError)
+				break
+			}
+		}
+		cellErrors = append(cellErrors, cellError)
+	}
+	return result, cellErrors
+}
+
+type fieldError struct {
+	field   string
+	tag     string
+	param   string
+	message string
+}
+
+func validateRow(v any) (fieldErrors []fieldError) {
+	defer func() {
+		if r := recover(); r != nil {
+			message := fmt.Sprint(r)
+			if result, ok := r.(resp.Result); ok {
+				message = result.Message
+			}
+			fieldErrors = append(fieldErrors, fieldError{message: message})
+		}
+	}()
+	err := binding.Validator.ValidateStruct(v)
+	if err == nil {
+		return nil
+	}
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []fieldError{{message: err.Error()}}
+	}
+	for _, e := range validationErrors {
+		_, field, _ := strings.Cut(e.StructNamespace(), ".")
+		fieldErrors = append(fieldErrors, fieldError{field: field, tag: e.Tag(), param: e.Param(), message: e.Error()})
+	}
+	return fieldErrors
+}
+
+func validationMessage(header string, fieldError fieldError) string {
+	switch fieldError.tag {
+	case "required":
+		return fmt.Sprintf("%s不能为空", header)
+	case "len":
+		return fmt.Sprintf("%s长度必须为%s", header, fieldError.param)
+	case "min":
+		return fmt.Sprintf("%s不能小于%s", header, fieldError.param)
+	case "max":
+		return fmt.Sprintf("%s不能大于%s", header, fieldError.param)
+	case "oneof":
+		return fmt.Sprintf("%s必须是[%s]中的一个值", header, fieldError.param)
+	}
+	return fmt.Sprintf("%s不满足%s校验", header, fieldError.tag)
+}
+
+func setCell(field reflect.Value, cell string, serial bool) error {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return nil
+	}
+	if field.Kind() == reflect.Ptr {
+		value := reflect.New(field.Type().Elem())
+		if err := setCell(value.Elem(), cell, serial); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+	if tm, ok := excelTime(field.Type(), cell, serial); ok {
+		if field.Type().ConvertibleTo(timeType) {
+			field.Set(reflect.ValueOf(tm).Convert(field.Type()))
+			return nil
+		}
+		cell = tm.Format(time.RFC3339)
+	}
+	if unmarshaler, ok := field.Addr().Interface().(LabelUnmarshaler); ok {
+		if err := unmarshaler.UnmarshalLabel(cell); err == nil {
+			return nil
+		}
+	}
+	if unmarshaler, ok := field.Addr().Interface().(ParamUnmarshaler); ok {
+		return unmarshaler.UnmarshalParam(cell)
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(cell, 10, field.Type().Bits())
+		if errors.Is(err, strconv.ErrSyntax) {
+			var float float64
+			if float, err = exactInteger(cell); err == nil {
+				number = int64(float)
+				if field.OverflowInt(number) {
+					err = strconv.ErrRange
+				}
+			}
+		}
+		if err != nil {
+			return integerError(cell, err)
+		}
+		field.SetInt(number)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := strconv.ParseUint(cell, 10, field.Type().Bits())
+		if errors.Is(err, strconv.ErrSyntax) {
+			var float float64
+			if float, err = exactInteger(cell); err == nil {
+				if float < 0 {
+					err = strconv.ErrSyntax
+				} else if number = uint64(float); field.OverflowUint(number) {
+					err = strconv.ErrRange
+				}
+			}
+		}
+		if err != nil {
+			return integerError(cell, err)
+		}
+		field.SetUint(number)
+	case reflect.Float32, reflect.Float64:
+		number, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return fmt.Errorf("%s不是有效的数字", cell)
+		}
+		field.SetFloat(number)
+	case reflect.Bool:
+		switch strings.ToLower(cell) {
+		case "1", "true", "是", "y", "yes":
+			field.SetBool(true)
+		case "0", "false", "否", "n", "no":
+			field.SetBool(false)
+		default:
+			return fmt.Errorf("%s不是有效的布尔值", cell)
+		}
+	default:
+		return fmt.Errorf("不支持的类型%s", field.Type())
+	}
+	return nil
+}
+
+func excelTime(fieldType reflect.Type, cell string, serial bool) (time.Time, bool) {
+	if !fieldType.ConvertibleTo(timeType) && fieldType != nullDateTimeType {
+		return time.Time{}, false
+	}
+	number, err := strconv.ParseFloat(cell, 64)
+	if !serial || err != nil {
+		if fieldType.ConvertibleTo(timeType) {
+			if tm, err := types.GetDateTimeFormat().Parse(cell); err == nil {
+				return tm, true
+			}
+		}
+		return time.Time{}, false
+	}
+	tm, err := excelize.ExcelDateToTime(number, false)
+	if err != nil {
+		return time.Time{}, false
+	}
+	location := types.GetDateTimeFormat().Location
+	return time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour(), tm.Minute(), tm.Second(), tm.Nanosecond(), location), true
+}
+
+func formattedCell(col column, cell string) string {
+	if col.dict != nil {
+		if code, ok := col.dict.Code(strings.TrimSpace(cell)); ok {
+			return code
+		}
+		return cell
+	}
+	if col.format == "" || (!col.kind.ConvertibleTo(timeType) && col.kind != nullDateTimeType) {
+		return cell
+	}
+	tm, err := time.ParseInLocation(goLayout(col), strings.TrimSpace(cell), types.GetDateTimeFormat().Location)
+	if err != nil {
+		return cell
+	}
+	return tm.Format(time.RFC3339Nano)
+}
+
+func blankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// exactInteger accepts integers that spreadsheets render as floats, such as
+// 12.0 or 1.2E+3, as long as float64 represents them exactly.
+func exactInteger(cell string) (float64, error) {
+	number, err := strconv.ParseFloat(cell, 64)
+	if err != nil || number != math.Trunc(number) {
+		return 0, strconv.ErrSyntax
+	}
+	if math.Abs(number) > 1<<53 {
+		return 0, strconv.ErrRange
+	}
+	return number, nil
+}
+
+func integerError(cell string, err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return fmt.Errorf("%s超出整数范围", cell)
+	}
+	return fmt.Errorf("%s不是有效的整数", cell)
+}
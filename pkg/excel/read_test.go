@@ -0,0 +1,121 @@
+package excel
+
+import (
+	"bytes"
+	"errors"
+	"github.com/xuri/excelize/v2"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSetCell(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  any
+		cell    string
+		want    any
+		wantErr string
+	}{
+		{name: "int", target: new(int), cell: " 42 ", want: 42},
+		{name: "int64 max", target: new(int64), cell: "9223372036854775807", want: int64(math.MaxInt64)},
+		{name: "int64 beyond float precision", target: new(int64), cell: "9007199254740993", want: int64(9007199254740993)},
+		{name: "int64 overflow", target: new(int64), cell: "9223372036854775808", wantErr: "超出整数范围"},
+		{name: "int8 overflow", target: new(int8), cell: "128", wantErr: "超出整数范围"},
+		{name: "int8 min", target: new(int8), cell: "-128", want: int8(-128)},
+		{name: "int from float rendering", target: new(int), cell: "12.0", want: 12},
+		{name: "int from exponent", target: new(int32), cell: "1.2E+3", want: int32(1200)},
+		{name: "int8 overflow from float rendering", target: new(int8), cell: "300.0", wantErr: "超出整数范围"},
+		{name: "int from inexact float", target: new(int64), cell: "1e20", wantErr: "超出整数范围"},
+		{name: "int fraction", target: new(int), cell: "1.5", wantErr: "不是有效的整数"},
+		{name: "int text", target: new(int), cell: "abc", wantErr: "不是有效的整数"},
+		{name: "uint64 max", target: new(uint64), cell: "18446744073709551615", want: uint64(math.MaxUint64)},
+		{name: "uint64 overflow", target: new(uint64), cell: "18446744073709551616", wantErr: "超出整数范围"},
+		{name: "uint negative", target: new(uint), cell: "-1", wantErr: "不是有效的整数"},
+		{name: "uint negative float", target: new(uint), cell: "-1.0", wantErr: "不是有效的整数"},
+		{name: "uint8 overflow", target: new(uint8), cell: "256", wantErr: "超出整数范围"},
+		{name: "float", target: new(float64), cell: "1.25", want: 1.25},
+		{name: "bool", target: new(bool), cell: "是", want: true},
+		{name: "bool invalid", target: new(bool), cell: "maybe", wantErr: "不是有效的布尔值"},
+		{name: "string", target: new(string), cell: "text", want: "text"},
+		{name: "pointer", target: new(*int), cell: "7", want: 7},
+		{name: "empty keeps zero", target: new(int), cell: "  ", want: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := reflect.ValueOf(test.target).Elem()
+			err := setCell(field, test.cell, false)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("err = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for field.Kind() == reflect.Ptr {
+				field = field.Elem()
+			}
+			if got := field.Interface(); got != test.want {
+				t.Fatalf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+type readRecord struct {
+	ID    int64  `excel:"编号"`
+	Count uint8  `excel:"数量"`
+	Name  string `excel:"名称"`
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    [][]any
+		want    []readRecord
+		wantErr []CellError
+	}{
+		{
+			name: "values",
+			rows: [][]any{{"编号", "数量", "名称"}, {"9007199254740993", 3, "a"}, {12, "255", "b"}},
+			want: []readRecord{{ID: 9007199254740993, Count: 3, Name: "a"}, {ID: 12, Count: 255, Name: "b"}},
+		},
+		{
+			name:    "overflow",
+			rows:    [][]any{{"编号", "数量", "名称"}, {"1", "256", "a"}},
+			wantErr: []CellError{{Row: 2, Column: 2, Header: "数量", Message: "256超出整数范围"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := excelize.NewFile()
+			for i, row := range test.rows {
+				if err := f.SetSheetRow("Sheet1", "A"+strconv.Itoa(i+1), &row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			buffer := &bytes.Buffer{}
+			if err := f.Write(buffer); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Read[readRecord](buffer)
+			if test.wantErr != nil {
+				var readError ReadError
+				if !errors.As(err, &readError) || !reflect.DeepEqual([]CellError(readError), test.wantErr) {
+					t.Fatalf("err = %#v, want %#v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package excel
+
+import (
+	"fmt"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var placeholder = regexp.MustCompile(`\{\{\s*\.([\p{L}\w]+(?:\.[\p{L}\w]+)*)\s*}}`)
+
+type templateRow struct {
+	rowNum int
+	cells  []string
+	slice  reflect.Value
+	path   []string
+}
+
+func RenderFile(template string, data any, filename string) error {
+	f, err := os.Open(template)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return write(filename, func(out *os.File) error {
+		return Render(f, data, out)
+	})
+}
+
+func Render(template io.Reader, data any, writer io.Writer) error {
+	excel, err := excelize.OpenReader(template)
+	if err != nil {
+		return err
+	}
+	defer excel.Close()
+	if err = RenderWorkbook(excel, data); err != nil {
+		return err
+	}
+	_, err = excel.WriteTo(writer)
+	return err
+}
+
+func RenderWorkbook(excel *excelize.File, data any) error {
+	root := reflect.ValueOf(data)
+	for _, sheet := range excel.GetSheetList() {
+		if err := renderSheet(excel, sheet, root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderSheet(excel *excelize.File, sheet string, root reflect.Value) error {
+	rows, err := excel.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return err
+	}
+	var templateRows []templateRow
+	for i, cells := range rows {
+		row := templateRow{rowNum: i + 1, cells: cells}
+		found := false
+		for _, cell := range cells {
+			for _, match := range placeholder.FindAllStringSubmatch(cell, -1) {
+				found = true
+				path := strings.Split(match[1], ".")
+				slice, rest, ok := sliceOf(root, path)
+				if !ok {
+					continue
+				}
+				if row.slice.IsValid() && strings.Join(row.path, ".") != strings.Join(path[:len(path)-len(rest)], ".") {
+					return fmt.Errorf("模板第%d行引用了多个列表", row.rowNum)
+				}
+				row.slice, row.path = slice, path[:len(path)-len(rest)]
+			}
+		}
+		if found {
+			templateRows = append(templateRows, row)
+		}
+	}
+	for i := len(templateRows) - 1; i >= 0; i-- {
+		row := templateRows[i]
+		if !row.slice.IsValid() {
+			if err = renderRow(excel, sheet, row.rowNum, row.cells, root, nil, reflect.Value{}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = repeatRow(excel, sheet, row, root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func repeatRow(excel *excelize.File, sheet string, row templateRow, root reflect.Value) error {
+	count := row.slice.Len()
+	if count == 0 {
+		return excel.RemoveRow(sheet, row.rowNum)
+	}
+	if count > 1 {
+		if err := excel.InsertRows(sheet, row.rowNum+1, count-1); err != nil {
+			return err
+		}
+		if err := copyRow(excel, sheet, row.rowNum, len(row.cells), count-1); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < count; i++ {
+		if err := renderRow(excel, sheet, row.rowNum+i, row.cells, root, row.path, row.slice.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyRow(excel *excelize.File, sheet string, rowNum int, width int, copies int) error {
+	height, err := excel.GetRowHeight(sheet, rowNum)
+	if err != nil {
+		return err
+	}
+	styles := make([]int, width)
+	for col := 1; col <= width; col++ {
+		cell, _ := excelize.CoordinatesToCellName(col, rowNum)
+		if styles[col-1], err = excel.GetCellStyle(sheet, cell); err != nil {
+			return err
+		}
+	}
+	mergeCells, err := excel.GetMergeCells(sheet)
+	if err != nil {
+		return err
+	}
+	var merges [][2]int
+	for _, mergeCell := range mergeCells {
+		startCol, startRow, _ := excelize.CellNameToCoordinates(mergeCell.GetStartAxis())
+		endCol, endRow, _ := excelize.CellNameToCoordinates(mergeCell.GetEndAxis())
+		if startRow == rowNum && endRow == rowNum {
+			merges = append(merges, [2]int{startCol, endCol})
+		}
+	}
+	for i := 1; i <= copies; i++ {
+		target := rowNum + i
+		if err = excel.SetRowHeight(sheet, target, height); err != nil {
+			return err
+		}
+		for col, style := range styles {
+			cell, _ := excelize.CoordinatesToCellName(col+1, target)
+			if err = excel.SetCellStyle(sheet, cell, cell, style); err != nil {
+				return err
+			}
+		}
+		for _, merge := range merges {
+			start, _ := excelize.CoordinatesToCellName(merge[0], target)
+			end, _ := excelize.CoordinatesToCellName(merge[1], target)
+			if err = excel.MergeCell(sheet, start, end); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func renderRow(excel *excelize.File, sheet string, rowNum int, cells []string, root reflect.Value, itemPath []string, item reflect.Value) error {
+	for i, text := range cells {
+		matches := placeholder.FindAllStringSubmatchIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		cell, _ := excelize.CoordinatesToCellName(i+1, rowNum)
+		resolve := func(name string) (any, error) {
+			path := strings.Split(name, ".")
+			if item.IsValid() && len(path) >= len(itemPath) && strings.Join(path[:len(itemPath)], ".") == strings.Join(itemPath, ".") {
+				return placeholderValue(item, path[len(itemPath):], name)
+			}
+			return placeholderValue(root, path, name)
+		}
+		if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(text) {
+			value, err := resolve(text[matches[0][2]:matches[0][3]])
+			if err != nil {
+				return err
+			}
+			if err = excel.SetCellValue(sheet, cell, value); err != nil {
+				return err
+			}
+			continue
+		}
+		builder := strings.Builder{}
+		last := 0
+		for _, match := range matches {
+			value, err := resolve(text[match[2]:match[3]])
+			if err != nil {
+				return err
+			}
+			builder.WriteString(text[last:match[0]])
+			builder.WriteString(textValue(value))
+			last = match[1]
+		}
+		builder.WriteString(text[last:])
+		if err := excel.SetCellValue(sheet, cell, builder.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func placeholderValue(value reflect.Value, path []string, name string) (any, error) {
+	for _, segment := range path {
+		var ok bool
+		if value, ok = lookup(value, segment); !ok {
+			return nil, fmt.Errorf("模板变量%s不存在", name)
+		}
+		if value.Kind() == reflect.Invalid {
+			return "", nil
+		}
+	}
+	return cellValue(value, column{}), nil
+}
+
+func sliceOf(value reflect.Value, path []string) (reflect.Value, []string, bool) {
+	for i, segment := range path {
+		var ok bool
+		if value, ok = lookup(value, segment); !ok || !value.IsValid() {
+			return reflect.Value{}, nil, false
+		}
+		for value.Kind() == reflect.Interface && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+			return value, path[i+1:], true
+		}
+	}
+	return reflect.Value{}, nil, false
+}
+
+func lookup(value reflect.Value, name string) (reflect.Value, bool) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}, true
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		field, ok := value.Type().FieldByName(name)
+		if !ok || !field.IsExported() {
+			return reflect.Value{}, false
+		}
+		value = value.FieldByIndex(field.Index)
+	case reflect.Map:
+		keyType := value.Type().Key()
+		if keyType.Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		value = value.MapIndex(reflect.ValueOf(name).Convert(keyType))
+	default:
+		return reflect.Value{}, false
+	}
+	return value, value.IsValid()
+}
+
+func textValue(value any) string {
+	if tm, ok := value.(time.Time); ok {
+		return tm.Format(types.GetDateTimeFormat().Layout)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,131 @@
+package excel
+
+import (
+	"bytes"
+	"github.com/xuri/excelize/v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type renderKey string
+
+type renderItem struct {
+	Name  string
+	Count int
+}
+
+type renderData struct {
+	Title  string
+	Items  []renderItem
+	Labels map[renderKey]string
+	Extra  map[string]any
+	Owner  *renderItem
+	secret string
+}
+
+func TestRender(t *testing.T) {
+	data := renderData{
+		Title:  "报表",
+		Items:  []renderItem{{Name: "a", Count: 1}, {Name: "b", Count: 2}},
+		Labels: map[renderKey]string{"total": "合计"},
+		Extra:  map[string]any{"note": "备注"},
+		secret: "hidden",
+	}
+	tests := []struct {
+		name    string
+		data    any
+		cells   []string
+		want    [][]string
+		wantErr string
+	}{
+		{
+			name:  "scalar",
+			data:  data,
+			cells: []string{"{{ .Title }}", "标题：{{.Title}}"},
+			want:  [][]string{{"报表", "标题：报表"}},
+		},
+		{
+			name:  "list rows",
+			data:  &data,
+			cells: []string{"{{.Items.Name}}", "{{.Items.Count}}"},
+			want:  [][]string{{"a", "1"}, {"b", "2"}},
+		},
+		{
+			name:  "named string map key",
+			data:  data,
+			cells: []string{"{{.Labels.total}}"},
+			want:  [][]string{{"合计"}},
+		},
+		{
+			name:  "interface map",
+			data:  data,
+			cells: []string{"{{.Extra.note}}"},
+			want:  [][]string{{"备注"}},
+		},
+		{
+			name:  "nil pointer",
+			data:  data,
+			cells: []string{"{{.Owner.Name}}", "x"},
+			want:  [][]string{{"", "x"}},
+		},
+		{
+			name:    "unexported field",
+			data:    data,
+			cells:   []string{"{{.secret}}"},
+			wantErr: "模板变量secret不存在",
+		},
+		{
+			name:    "missing field",
+			data:    data,
+			cells:   []string{"{{.Missing}}"},
+			wantErr: "模板变量Missing不存在",
+		},
+		{
+			name:    "missing map key",
+			data:    data,
+			cells:   []string{"{{.Labels.none}}"},
+			wantErr: "模板变量Labels.none不存在",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			template := excelize.NewFile()
+			defer template.Close()
+			sheet := template.GetSheetName(0)
+			for i, cell := range test.cells {
+				name, _ := excelize.CoordinatesToCellName(i+1, 1)
+				if err := template.SetCellValue(sheet, name, cell); err != nil {
+					t.Fatal(err)
+				}
+			}
+			input := &bytes.Buffer{}
+			if err := template.Write(input); err != nil {
+				t.Fatal(err)
+			}
+			output := &bytes.Buffer{}
+			err := Render(input, test.data, output)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("err = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := excelize.OpenReader(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer result.Close()
+			rows, err := result.GetRows(sheet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, test.want) {
+				t.Fatalf("rows = %q, want %q", rows, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package excel
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strings"
+)
+
+const (
+	errorHeader     = "错误信息"
+	errorFillColor  = "FFC7CE"
+	errorFontColor  = "9C0006"
+	errorColumnSize = 60
+)
+
+type ImportResult struct {
+	Total   int       `json:"total"`
+	Success int       `json:"success"`
+	Errors  ReadError `json:"errors"`
+	source  []byte
+}
+
+func Import[T any](reader io.Reader, fn func(row int, data T) error) (*ImportResult, error) {
+	source, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	result := &ImportResult{source: source}
+	err = StreamRead(bytes.NewReader(source), func(row int, data T) error {
+		if err := fn(row, data); err != nil {
+			return err
+		}
+		result.Success++
+		return nil
+	})
+	var readError ReadError
+	if err != nil && !errors.As(err, &readError) {
+		return nil, err
+	}
+	result.Errors = readError
+	rows := map[int]bool{}
+	for _, cellError := range readError {
+		if cellError.Row > 1 {
+			rows[cellError.Row] = true
+		}
+	}
+	result.Total = result.Success + len(rows)
+	return result, nil
+}
+
+func (result *ImportResult) Failed() bool {
+	return len(result.Errors) > 0
+}
+
+func (result *ImportResult) Report(writer io.Writer) error {
+	return Annotate(bytes.NewReader(result.source), result.Errors, writer)
+}
+
+func (result *ImportResult) WriteReport(ctx *gin.Context, filename string) {
+	var buffer bytes.Buffer
+	if err := result.Report(&buffer); err != nil {
+		panic(err)
+	}
+	Attachment(ctx, filename, buffer.Bytes())
+}
+
+func Annotate(reader io.Reader, readError ReadError, writer io.Writer) error {
+	excel, err := excelize.OpenReader(reader)
+	if err != nil {
+		return err
+	}
+	defer excel.Close()
+	sheetName := excel.GetSheetName(0)
+	rows, err := excel.GetRows(sheetName)
+	if err != nil {
+		return err
+	}
+	errorColumn := 1
+	for _, row := range rows {
+		errorColumn = max(errorColumn, len(row)+1)
+	}
+	errorColumnName, _ := excelize.ColumnNumberToName(errorColumn)
+	headerStyle, err := excel.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Color: errorFontColor}})
+	if err != nil {
+		return err
+	}
+	messageStyle, err := excel.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Color: errorFontColor},
+		Alignment: &excelize.Alignment{WrapText: true, Vertical: "center"},
+	})
+	if err != nil {
+		return err
+	}
+	if err = excel.SetColWidth(sheetName, errorColumnName, errorColumnName, errorColumnSize); err != nil {
+		return err
+	}
+	headerCell, _ := excelize.CoordinatesToCellName(errorColumn, 1)
+	if err = excel.SetCellValue(sheetName, headerCell, errorHeader); err != nil {
+		return err
+	}
+	if err = excel.SetCellStyle(sheetName, headerCell, headerCell, headerStyle); err != nil {
+		return err
+	}
+	var rowNums []int
+	messages := map[int][]string{}
+	highlights := map[int]int{}
+	for _, cellError := range readError {
+		if _, ok := messages[cellError.Row]; !ok {
+			rowNums = append(rowNums, cellError.Row)
+		}
+		messages[cellError.Row] = append(messages[cellError.Row], cellError.Message)
+		if cell := cellError.Cell(); cell != "" {
+			if err = highlight(excel, sheetName, cell, highlights); err != nil {
+				return err
+			}
+		}
+	}
+	for _, rowNum := range rowNums {
+		cell, _ := excelize.CoordinatesToCellName(errorColumn, rowNum)
+		message := messages[rowNum]
+		if rowNum == 1 {
+			cell = headerCell
+			message = append([]string{errorHeader}, message...)
+		}
+		if err = excel.SetCellValue(sheetName, cell, strings.Join(message, "\n")); err != nil {
+			return err
+		}
+		if err = excel.SetCellStyle(sheetName, cell, cell, messageStyle); err != nil {
+			return err
+		}
+	}
+	return excel.Write(writer)
+}
+
+func highlight(excel *excelize.File, sheetName string, cell string, highlights map[int]int) error {
+	styleID, err := excel.GetCellStyle(sheetName, cell)
+	if err != nil {
+		return err
+	}
+	errorStyleID, ok := highlights[styleID]
+	if !ok {
+		style, err := excel.GetStyle(styleID)
+		if err != nil {
+			return err
+		}
+		style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{errorFillColor}}
+		if style.Font == nil {
+			style.Font = &excelize.Font{}
+		}
+		style.Font.Color = errorFontColor
+		if errorStyleID, err = excel.NewStyle(style); err != nil {
+			return err
+		}
+		highlights[styleID] = errorStyleID
+	}
+	return excel.SetCellStyle(sheetName, cell, cell, errorStyleID)
+}
@@ -0,0 +1,210 @@
+package excel
+
+import (
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultDateTimeFormat = "yyyy-mm-dd hh:mm:ss"
+	defaultDateFormat     = "yyyy-mm-dd"
+	autoWidthSampleRows   = 1000
+	minAutoWidth          = 8.0
+	maxAutoWidth          = 80.0
+)
+
+var DefaultHeaderStyle = &excelize.Style{
+	Font:      &excelize.Font{Bold: true},
+	Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
+	Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+}
+
+type Option func(options *options)
+
+type options struct {
+	headerStyle  *excelize.Style
+	freezeHeader bool
+	autoFilter   bool
+	autoWidth    bool
+	validation   bool
+	rowStyle     func(row int, data any) *excelize.Style
+}
+
+func HeaderStyle(style *excelize.Style) Option {
+	return func(options *options) {
+		options.headerStyle = style
+	}
+}
+
+func FreezeHeader() Option {
+	return func(options *options) {
+		options.freezeHeader = true
+	}
+}
+
+func AutoFilter() Option {
+	return func(options *options) {
+		options.autoFilter = true
+	}
+}
+
+func AutoWidth() Option {
+	return func(options *options) {
+		options.autoWidth = true
+	}
+}
+
+func RowStyle[T any](fn func(row int, data T) *excelize.Style) Option {
+	return func(options *options) {
+		options.rowStyle = func(row int, data any) *excelize.Style {
+			if value, ok := data.(T); ok {
+				return fn(row, value)
+			}
+			return nil
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	options := &options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+type sheetStyle struct {
+	excel  *excelize.File
+	base   []excelize.Style
+	ids    []int
+	header int
+	rows   map[*excelize.Style][]int
+}
+
+func newSheetStyle(excel *excelize.File, cols []column, options *options) (*sheetStyle, error) {
+	style := &sheetStyle{
+		excel: excel,
+		base:  make([]excelize.Style, len(cols)),
+		ids:   make([]int, len(cols)),
+		rows:  map[*excelize.Style][]int{},
+	}
+	for i, col := range cols {
+		style.base[i] = excelize.Style{
+			Alignment: &excelize.Alignment{
+				Horizontal: col.align,
+				Vertical:   "center",
+				WrapText:   col.wrap,
+			},
+		}
+		if format := col.numberFormat(); format != "" {
+			style.base[i].CustomNumFmt = &format
+		}
+		id, err := excel.NewStyle(&style.base[i])
+		if err != nil {
+			return nil, err
+		}
+		style.ids[i] = id
+	}
+	if options.headerStyle != nil {
+		id, err := excel.NewStyle(options.headerStyle)
+		if err != nil {
+			return nil, err
+		}
+		style.header = id
+	}
+	return style, nil
+}
+
+func (style *sheetStyle) headerIDs() []int {
+	if style.header == 0 {
+		return style.ids
+	}
+	ids := make([]int, len(style.ids))
+	for i := range ids {
+		ids[i] = style.header
+	}
+	return ids
+}
+
+func (style *sheetStyle) rowIDs(rowStyle *excelize.Style) ([]int, error) {
+	if rowStyle == nil {
+		return nil, nil
+	}
+	if ids, ok := style.rows[rowStyle]; ok {
+		return ids, nil
+	}
+	ids := make([]int, len(style.base))
+	for i, base := range style.base {
+		merged := base
+		if rowStyle.Font != nil {
+			merged.Font = rowStyle.Font
+		}
+		if rowStyle.Fill.Type != "" {
+			merged.Fill = rowStyle.Fill
+		}
+		if len(rowStyle.Border) > 0 {
+			merged.Border = rowStyle.Border
+		}
+		if rowStyle.Alignment != nil {
+			merged.Alignment = rowStyle.Alignment
+		}
+		if rowStyle.CustomNumFmt != nil {
+			merged.CustomNumFmt = rowStyle.CustomNumFmt
+		}
+		id, err := style.excel.NewStyle(&merged)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	style.rows[rowStyle] = ids
+	return ids, nil
+}
+
+type columnWidths []float64
+
+func newColumnWidths(cols []column) columnWidths {
+	widths := make(columnWidths, len(cols))
+	for i, col := range cols {
+		widths[i] = displayWidth(col.header)
+	}
+	return widths
+}
+
+func (widths columnWidths) measure(cols []column, row []any) {
+	for i, value := range row {
+		var width float64
+		switch v := value.(type) {
+		case time.Time:
+			width = displayWidth(cols[i].numberFormat())
+		case string:
+			width = displayWidth(v)
+		default:
+			width = displayWidth(fmt.Sprint(v))
+		}
+		widths[i] = max(widths[i], width)
+	}
+}
+
+func (widths columnWidths) width(i int) float64 {
+	return min(max(widths[i]+2, minAutoWidth), maxAutoWidth)
+}
+
+func displayWidth(text string) float64 {
+	width := 0.0
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		if r == '\n' {
+			break
+		}
+		if size > 1 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
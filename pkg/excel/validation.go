@@ -0,0 +1,144 @@
+package excel
+
+import (
+	"fmt"
+	"github.com/misakacoder/inuyasha/http/req"
+	"github.com/xuri/excelize/v2"
+	"reflect"
+	"strings"
+)
+
+const (
+	lookupSheetName   = "_lookup"
+	maxDropListLength = 255
+	requiredComment   = "必填"
+)
+
+var enumType = reflect.TypeOf((*req.Enum)(nil)).Elem()
+
+type lookupSheet struct {
+	columns int
+	ranges  map[string]string
+}
+
+func DataValidation() Option {
+	return func(options *options) {
+		options.validation = true
+	}
+}
+
+func Template[T any](options ...Option) (*Workbook, error) {
+	workbook := NewWorkbook()
+	options = append([]Option{HeaderStyle(DefaultHeaderStyle), FreezeHeader(), DataValidation()}, options...)
+	if err := AddSheet(workbook, "", []T(nil), options...); err != nil {
+		_ = workbook.Close()
+		return nil, err
+	}
+	return workbook, nil
+}
+
+func (col column) dropList() []string {
+	if items, ok := col.dict.(interface{ Items() []DictItem }); ok {
+		labels := make([]string, len(items.Items()))
+		for i, item := range items.Items() {
+			labels[i] = item.Label
+		}
+		return labels
+	}
+	if col.kind.Implements(enumType) {
+		values := reflect.Zero(col.kind).Interface().(req.Enum).Values()
+		labels := make([]string, len(values))
+		for i, value := range values {
+			if labeler, ok := value.(Labeler); ok {
+				labels[i] = labeler.Label()
+			} else {
+				labels[i] = fmt.Sprint(value)
+			}
+		}
+		return labels
+	}
+	return nil
+}
+
+func (col column) comment() string {
+	comment := col.hint
+	if col.required {
+		comment = strings.TrimSpace(requiredComment + " " + comment)
+	}
+	return comment
+}
+
+func (sheet *sheetWriter[T]) validate() error {
+	excel := sheet.workbook.excel
+	for i, col := range sheet.cols {
+		colName, _ := excelize.ColumnNumberToName(i + 1)
+		if comment := col.comment(); comment != "" {
+			err := excel.AddComment(sheet.sheetName, excelize.Comment{
+				Cell:      colName + "1",
+				Paragraph: []excelize.RichTextRun{{Text: comment}},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		list := col.dropList()
+		if len(list) == 0 {
+			continue
+		}
+		validation := excelize.NewDataValidation(true)
+		validation.Sqref = fmt.Sprintf("%s2:%s%d", colName, colName, maxSheetRows)
+		validation.SetError(excelize.DataValidationErrorStyleStop, col.header, fmt.Sprintf("请从下拉列表中选择%s", col.header))
+		if dropListLength(list) <= maxDropListLength {
+			if err := validation.SetDropList(list); err != nil {
+				return err
+			}
+		} else {
+			lookupRange, err := sheet.workbook.lookupRange(list)
+			if err != nil {
+				return err
+			}
+			validation.SetSqrefDropList(lookupRange)
+		}
+		if err := excel.AddDataValidation(sheet.sheetName, validation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (workbook *Workbook) lookupRange(list []string) (string, error) {
+	key := strings.Join(list, "\x00")
+	if workbook.lookup == nil {
+		if _, err := workbook.excel.NewSheet(lookupSheetName); err != nil {
+			return "", err
+		}
+		if err := workbook.excel.SetSheetVisible(lookupSheetName, false, true); err != nil {
+			return "", err
+		}
+		workbook.lookup = &lookupSheet{ranges: map[string]string{}}
+	}
+	if lookupRange, ok := workbook.lookup.ranges[key]; ok {
+		return lookupRange, nil
+	}
+	workbook.lookup.columns++
+	colName, _ := excelize.ColumnNumberToName(workbook.lookup.columns)
+	for i, value := range list {
+		if err := workbook.excel.SetCellStr(lookupSheetName, fmt.Sprintf("%s%d", colName, i+1), value); err != nil {
+			return "", err
+		}
+	}
+	lookupRange := fmt.Sprintf("'%s'!$%s$1:$%s$%d", lookupSheetName, colName, colName, len(list))
+	workbook.lookup.ranges[key] = lookupRange
+	return lookupRange, nil
+}
+
+func dropListLength(list []string) int {
+	length := 0
+	for _, value := range list {
+		if strings.ContainsAny(value, ",\"") {
+			return maxDropListLength + 1
+		}
+		length += len(value) + 1
+	}
+	return length
+}
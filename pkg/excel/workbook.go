@@ -0,0 +1,346 @@
+package excel
+
+import (
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+const (
+	maxSheetNameLength = 31
+	maxSheetRows       = excelize.TotalRows
+)
+
+type Workbook struct {
+	excel  *excelize.File
+	sheets int
+	lookup *lookupSheet
+}
+
+type rowWriter interface {
+	setRow(rowNum int, row []any, styles []int) error
+	setPanes(panes *excelize.Panes) error
+	flush() error
+}
+
+type sheetRowWriter struct {
+	excel     *excelize.File
+	sheetName string
+}
+
+func (writer *sheetRowWriter) setRow(rowNum int, row []any, styles []int) error {
+	cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+	if err := writer.excel.SetSheetRow(writer.sheetName, cell, &row); err != nil {
+		return err
+	}
+	for i, style := range styles {
+		cell, _ = excelize.CoordinatesToCellName(i+1, rowNum)
+		if err := writer.excel.SetCellStyle(writer.sheetName, cell, cell, style); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (writer *sheetRowWriter) setPanes(panes *excelize.Panes) error {
+	return writer.excel.SetPanes(writer.sheetName, panes)
+}
+
+func (writer *sheetRowWriter) flush() error {
+	return nil
+}
+
+type streamRowWriter struct {
+	*excelize.StreamWriter
+	styles []int
+}
+
+func (writer *streamRowWriter) setRow(rowNum int, row []any, styles []int) error {
+	if styles == nil {
+		styles = writer.styles
+	}
+	cells := make([]any, len(row))
+	for i, value := range row {
+		cells[i] = excelize.Cell{StyleID: styles[i], Value: value}
+	}
+	cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+	return writer.SetRow(cell, cells)
+}
+
+func (writer *streamRowWriter) setPanes(panes *excelize.Panes) error {
+	return writer.SetPanes(panes)
+}
+
+func (writer *streamRowWriter) flush() error {
+	return writer.Flush()
+}
+
+type pendingRow struct {
+	rowNum int
+	row    []any
+	styles []int
+}
+
+func NewWorkbook() *Workbook {
+	return &Workbook{excel: excelize.NewFile()}
+}
+
+func AddSheet[T any](workbook *Workbook, name string, data []T, options ...Option) error {
+	index := 0
+	return writeSheet(workbook, name, false, newOptions(options), func() (T, error) {
+		var result T
+		if index >= len(data) {
+			return result, io.EOF
+		}
+		result = data[index]
+		index++
+		return result, nil
+	})
+}
+
+func AddStreamSheet[T any](workbook *Workbook, name string, reader Reader[T], options ...Option) error {
+	return writeSheet(workbook, name, true, newOptions(options), reader.Read)
+}
+
+func (workbook *Workbook) File() *excelize.File {
+	return workbook.excel
+}
+
+func (workbook *Workbook) Write(writer io.Writer) error {
+	return workbook.excel.Write(writer)
+}
+
+func (workbook *Workbook) WriteFile(filename string) error {
+	return write(filename, func(f *os.File) error {
+		return workbook.Write(f)
+	})
+}
+
+func (workbook *Workbook) Close() error {
+	return workbook.excel.Close()
+}
+
+func (workbook *Workbook) newSheet(name string) (string, error) {
+	if index, _ := workbook.excel.GetSheetIndex(name); index >= 0 && workbook.sheets > 0 {
+		return "", fmt.Errorf("sheet %s already exists", name)
+	}
+	if workbook.sheets == 0 {
+		if err := workbook.excel.SetSheetName(workbook.excel.GetSheetName(0), name); err != nil {
+			return "", err
+		}
+	} else if _, err := workbook.excel.NewSheet(name); err != nil {
+		return "", err
+	}
+	workbook.sheets++
+	return name, nil
+}
+
+func (workbook *Workbook) rowWriter(sheetName string, stream bool, style *sheetStyle) (rowWriter, error) {
+	if stream {
+		streamWriter, err := workbook.excel.NewStreamWriter(sheetName)
+		if err != nil {
+			return nil, err
+		}
+		return &streamRowWriter{StreamWriter: streamWriter, styles: style.ids}, nil
+	}
+	return &sheetRowWriter{excel: workbook.excel, sheetName: sheetName}, nil
+}
+
+type sheetWriter[T any] struct {
+	workbook  *Workbook
+	name      string
+	stream    bool
+	options   *options
+	cols      []column
+	style     *sheetStyle
+	part      int
+	sheetName string
+	writer    rowWriter
+	rowNum    int
+	widths    columnWidths
+	header    bool
+	pending   []pendingRow
+}
+
+func writeSheet[T any](workbook *Workbook, name string, stream bool, options *options, next func() (T, error)) error {
+	cols := columns[T]()
+	if options.autoWidth {
+		for i := range cols {
+			cols[i].autoWidth = true
+		}
+	}
+	style, err := newSheetStyle(workbook.excel, cols, options)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = workbook.excel.GetSheetName(0)
+	}
+	sheet := &sheetWriter[T]{workbook: workbook, name: name, stream: stream, options: options, cols: cols, style: style}
+	if err = sheet.open(); err != nil {
+		return err
+	}
+	row := make([]any, len(cols))
+	for {
+		data, err := next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if sheet.rowNum > maxSheetRows {
+			if err = sheet.close(); err != nil {
+				return err
+			}
+			if err = sheet.open(); err != nil {
+				return err
+			}
+		}
+		valid, err := setRow(data, cols, row, func(row []any) error {
+			return sheet.write(data, row)
+		})
+		if err != nil {
+			return err
+		}
+		if valid {
+			sheet.rowNum++
+		}
+	}
+	return sheet.close()
+}
+
+func (sheet *sheetWriter[T]) open() error {
+	sheet.part++
+	sheetName, err := sheet.workbook.newSheet(sheetName(sheet.name, sheet.part))
+	if err != nil {
+		return err
+	}
+	if sheet.writer, err = sheet.workbook.rowWriter(sheetName, sheet.stream, sheet.style); err != nil {
+		return err
+	}
+	sheet.sheetName = sheetName
+	sheet.rowNum = 2
+	sheet.widths = newColumnWidths(sheet.cols)
+	sheet.header = false
+	sheet.pending = nil
+	if !sheet.stream || !sheet.autoWidth() {
+		return sheet.writeHeader()
+	}
+	return nil
+}
+
+func (sheet *sheetWriter[T]) write(data T, row []any) error {
+	var styles []int
+	if sheet.options.rowStyle != nil {
+		var err error
+		if styles, err = sheet.style.rowIDs(sheet.options.rowStyle(sheet.rowNum, data)); err != nil {
+			return err
+		}
+	}
+	if sheet.autoWidth() {
+		sheet.widths.measure(sheet.cols, row)
+	}
+	if sheet.header {
+		return sheet.writer.setRow(sheet.rowNum, row, styles)
+	}
+	sheet.pending = append(sheet.pending, pendingRow{rowNum: sheet.rowNum, row: append([]any{}, row...), styles: styles})
+	if len(sheet.pending) >= autoWidthSampleRows {
+		return sheet.writeHeader()
+	}
+	return nil
+}
+
+func (sheet *sheetWriter[T]) writeHeader() error {
+	excel := sheet.workbook.excel
+	headers := make([]any, len(sheet.cols))
+	for i, col := range sheet.cols {
+		headers[i] = col.header
+		colName, _ := excelize.ColumnNumberToName(i + 1)
+		width := col.width
+		if col.autoWidth {
+			width = sheet.widths.width(i)
+		}
+		if err := excel.SetColWidth(sheet.sheetName, colName, colName, width); err != nil {
+			return err
+		}
+		if err := excel.SetColStyle(sheet.sheetName, colName, sheet.style.ids[i]); err != nil {
+			return err
+		}
+	}
+	if sheet.options.freezeHeader {
+		err := sheet.writer.setPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+		if err != nil {
+			return err
+		}
+	}
+	if err := sheet.writer.setRow(1, headers, sheet.style.headerIDs()); err != nil {
+		return err
+	}
+	sheet.header = true
+	for _, pending := range sheet.pending {
+		if err := sheet.writer.setRow(pending.rowNum, pending.row, pending.styles); err != nil {
+			return err
+		}
+	}
+	sheet.pending = nil
+	return nil
+}
+
+func (sheet *sheetWriter[T]) close() error {
+	if !sheet.header {
+		if err := sheet.writeHeader(); err != nil {
+			return err
+		}
+	}
+	excel := sheet.workbook.excel
+	if !sheet.stream {
+		for i, col := range sheet.cols {
+			if col.autoWidth {
+				colName, _ := excelize.ColumnNumberToName(i + 1)
+				if err := excel.SetColWidth(sheet.sheetName, colName, colName, sheet.widths.width(i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := sheet.writer.flush(); err != nil {
+		return err
+	}
+	if sheet.options.validation {
+		if err := sheet.validate(); err != nil {
+			return err
+		}
+	}
+	if sheet.options.autoFilter {
+		lastCell, _ := excelize.CoordinatesToCellName(len(sheet.cols), max(sheet.rowNum-1, 1))
+		return excel.AutoFilter(sheet.sheetName, "A1:"+lastCell, nil)
+	}
+	return nil
+}
+
+func (sheet *sheetWriter[T]) autoWidth() bool {
+	for _, col := range sheet.cols {
+		if col.autoWidth {
+			return true
+		}
+	}
+	return false
+}
+
+func sheetName(name string, part int) string {
+	if part <= 1 {
+		return truncateSheetName(name, maxSheetNameLength)
+	}
+	suffix := fmt.Sprintf(" (%d)", part)
+	return truncateSheetName(name, maxSheetNameLength-len(suffix)) + suffix
+}
+
+func truncateSheetName(name string, length int) string {
+	if utf8.RuneCountInString(name) <= length {
+		return name
+	}
+	return string([]rune(name)[:length])
+}
@@ -0,0 +1,43 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+)
+
+type Config struct {
+	Secret        string            `yaml:"secret"`
+	KeyID         string            `yaml:"keyId"`
+	PrivateKey    string            `yaml:"privateKey"`
+	PublicKeys    map[string]string `yaml:"publicKeys"`
+	Expire        time.Duration     `yaml:"expire"`
+	RefreshExpire time.Duration     `yaml:"refreshExpire"`
+}
+
+func New(conf Config, options ...Option) (Manager, error) {
+	var signingKey *Key
+	var err error
+	switch {
+	case conf.PrivateKey != "":
+		if signingKey, err = LoadPrivateKey(conf.KeyID, conf.PrivateKey); err != nil {
+			return nil, err
+		}
+	case conf.Secret != "":
+		signingKey = NewHMACKey(conf.KeyID, conf.Secret)
+	default:
+		return nil, errors.New("jwt secret or private key is required")
+	}
+	var verificationKeys []*Key
+	for id, filename := range conf.PublicKeys {
+		key, err := LoadPublicKey(id, filename)
+		if err != nil {
+			return nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+	options = append([]Option{VerificationKeys(verificationKeys...)}, options...)
+	if conf.RefreshExpire > 0 {
+		options = append([]Option{RefreshExpire(conf.RefreshExpire)}, options...)
+	}
+	return NewKeyManager(conf.Expire, signingKey, options...), nil
+}
@@ -0,0 +1,133 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+)
+
+var ErrInvalidPEM = errors.New("invalid pem key")
+
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   any
+	VerifyKey any
+}
+
+func (key *Key) CanSign() bool {
+	return key.SignKey != nil
+}
+
+func NewHMACKey(id string, secret string) *Key {
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, SignKey: []byte(secret), VerifyKey: []byte(secret)}
+}
+
+func NewKey(id string, privateKey crypto.Signer) (*Key, error) {
+	method, err := signingMethod(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: id, Method: method, SignKey: privateKey, VerifyKey: privateKey.Public()}, nil
+}
+
+func NewPublicKey(id string, publicKey crypto.PublicKey) (*Key, error) {
+	method, err := signingMethod(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: id, Method: method, VerifyKey: publicKey}, nil
+}
+
+func LoadPrivateKey(id string, filename string) (*Key, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(id, data)
+}
+
+func LoadPublicKey(id string, filename string) (*Key, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(id, data)
+}
+
+func ParsePrivateKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+	var privateKey any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T", privateKey)
+	}
+	return NewKey(id, signer)
+}
+
+func ParsePublicKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+	var publicKey any
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var certificate *x509.Certificate
+		if certificate, err = x509.ParseCertificate(block.Bytes); err == nil {
+			publicKey = certificate.PublicKey
+		}
+	default:
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicKey(id, publicKey)
+}
+
+func signingMethod(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, fmt.Errorf("unsupported curve %s", key.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported public key %T", publicKey)
+}
@@ -1,47 +1,291 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"sync"
 	"time"
 )
 
+const (
+	refreshToken        = "refresh"
+	familyPrefix        = "family:"
+	defaultRefreshTime  = 7 * 24 * time.Hour
+	defaultRefreshGrace = 10 * time.Second
+)
+
+var (
+	ErrTokenExpired       = jwt.ErrTokenExpired
+	ErrTokenRevoked       = errors.New("token revoked")
+	ErrInvalidTokenType   = errors.New("invalid token type")
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
 type Claims struct {
 	*jwt.RegisteredClaims
+	Type    string         `json:"typ,omitempty"`
+	Family  string         `json:"fam,omitempty"`
 	Payload map[string]any `json:"payload"`
 }
 
+type TokenPair struct {
+	AccessToken      string           `json:"accessToken"`
+	RefreshToken     string           `json:"refreshToken"`
+	ExpiresAt        *jwt.NumericDate `json:"expiresAt"`
+	RefreshExpiresAt *jwt.NumericDate `json:"refreshExpiresAt"`
+	Claims           *Claims          `json:"-"`
+}
+
 type Manager interface {
 	Create(subject string, payload map[string]any) (string, *Claims)
 	Parse(tokenString string) (*Claims, error)
+	CreatePair(subject string, payload map[string]any) (*TokenPair, error)
+	Refresh(refreshTokenString string) (*TokenPair, error)
+	Revoke(claims *Claims) error
+	RevokeSubject(subject string) error
 }
 
+type Option func(manager *manager)
+
 type manager struct {
-	secretKey  string
-	expireTime time.Duration
+	signingKey   *Key
+	keys         map[string]*Key
+	expireTime   time.Duration
+	refreshTime  time.Duration
+	refreshGrace time.Duration
+	refreshes    map[string]*refreshResult
+	store        RevocationStore
+	mutex        sync.RWMutex
+}
+
+type refreshResult struct {
+	done chan struct{}
+	pair *TokenPair
+	err  error
+}
+
+func VerificationKeys(keys ...*Key) Option {
+	return func(manager *manager) {
+		manager.AddKey(keys...)
+	}
+}
+
+func RefreshExpire(refreshTime time.Duration) Option {
+	return func(manager *manager) {
+		manager.refreshTime = refreshTime
+	}
+}
+
+// RefreshGrace sets how long the pair issued for a refresh token is handed out
+// again, so concurrent requests refreshing the same token are not taken for
+// reuse. Refreshing it after the grace window revokes the whole family.
+func RefreshGrace(grace time.Duration) Option {
+	return func(manager *manager) {
+		manager.refreshGrace = grace
+	}
+}
+
+func Revocation(store RevocationStore) Option {
+	return func(manager *manager) {
+		manager.store = store
+	}
 }
 
 func (manager *manager) Create(subject string, payload map[string]any) (string, *Claims) {
+	tokenString, claims, _ := manager.sign(subject, payload, "", "", manager.expireTime)
+	return tokenString, claims
+}
+
+func (manager *manager) Parse(tokenString string) (*Claims, error) {
+	claims, err := manager.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type == refreshToken {
+		return nil, ErrInvalidTokenType
+	}
+	if err = manager.checkRevoked(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (manager *manager) CreatePair(subject string, payload map[string]any) (*TokenPair, error) {
+	return manager.createPair(subject, payload, newID())
+}
+
+func (manager *manager) Refresh(refreshTokenString string) (*TokenPair, error) {
+	claims, err := manager.parse(refreshTokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != refreshToken {
+		return nil, ErrInvalidTokenType
+	}
+	if err = manager.checkSession(claims); err != nil {
+		return nil, err
+	}
+	manager.mutex.Lock()
+	result, ok := manager.refreshes[claims.ID]
+	if !ok {
+		result = &refreshResult{done: make(chan struct{})}
+		manager.refreshes[claims.ID] = result
+	}
+	manager.mutex.Unlock()
+	if ok {
+		<-result.done
+	} else {
+		result.pair, result.err = manager.refresh(claims)
+		close(result.done)
+		time.AfterFunc(manager.refreshGrace, func() {
+			manager.mutex.Lock()
+			defer manager.mutex.Unlock()
+			delete(manager.refreshes, claims.ID)
+		})
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	pair := *result.pair
+	return &pair, nil
+}
+
+func (manager *manager) refresh(claims *Claims) (*TokenPair, error) {
+	first, err := manager.store.Revoke(claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		if _, err = manager.store.Revoke(familyPrefix+claims.Family, time.Now().Add(manager.refreshTime)); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	return manager.createPair(claims.Subject, claims.Payload, claims.Family)
+}
+
+func (manager *manager) Revoke(claims *Claims) error {
+	if _, err := manager.store.Revoke(claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+	if claims.Family != "" {
+		_, err := manager.store.Revoke(familyPrefix+claims.Family, time.Now().Add(manager.refreshTime))
+		return err
+	}
+	return nil
+}
+
+func (manager *manager) RevokeSubject(subject string) error {
+	return manager.store.RevokeSubject(subject, time.Now())
+}
+
+func (manager *manager) Key(id string) *Key {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	if key, ok := manager.keys[id]; ok {
+		return key
+	}
+	if id == "" {
+		return manager.signingKey
+	}
+	return nil
+}
+
+func (manager *manager) AddKey(keys ...*Key) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for _, key := range keys {
+		manager.keys[key.ID] = key
+	}
+}
+
+func (manager *manager) RemoveKey(id string) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if manager.signingKey.ID != id {
+		delete(manager.keys, id)
+	}
+}
+
+func (manager *manager) Rotate(signingKey *Key) error {
+	if !signingKey.CanSign() {
+		return fmt.Errorf("key %s can not sign", signingKey.ID)
+	}
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	manager.signingKey = signingKey
+	manager.keys[signingKey.ID] = signingKey
+	return nil
+}
+
+func (manager *manager) JWKS() JWKSet {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	jwks := JWKSet{Keys: []JWK{}}
+	for _, key := range manager.keys {
+		if jwk, ok := key.JWK(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	return jwks
+}
+
+func (manager *manager) createPair(subject string, payload map[string]any, family string) (*TokenPair, error) {
+	access, claims, err := manager.sign(subject, payload, "", family, manager.expireTime)
+	if err != nil {
+		return nil, err
+	}
+	refresh, refreshClaims, err := manager.sign(subject, payload, refreshToken, family, manager.refreshTime)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		ExpiresAt:        claims.ExpiresAt,
+		RefreshExpiresAt: refreshClaims.ExpiresAt,
+		Claims:           claims,
+	}, nil
+}
+
+func (manager *manager) sign(subject string, payload map[string]any, tokenType string, family string, expireTime time.Duration) (string, *Claims, error) {
 	now := time.Now()
 	claims := &Claims{
 		RegisteredClaims: &jwt.RegisteredClaims{
+			ID:        newID(),
 			Subject:   subject,
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(now.Add(manager.expireTime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expireTime)),
 		},
+		Type:    tokenType,
+		Family:  family,
 		Payload: payload,
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, _ := token.SignedString([]byte(manager.secretKey))
-	return tokenString, claims
+	manager.mutex.RLock()
+	key := manager.signingKey
+	manager.mutex.RUnlock()
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	tokenString, err := token.SignedString(key.SignKey)
+	return tokenString, claims, err
 }
 
-func (manager *manager) Parse(tokenString string) (*Claims, error) {
+func (manager *manager) parse(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
-		if token.Method != jwt.SigningMethodHS256 {
+		kid, _ := token.Header["kid"].(string)
+		key := manager.Key(kid)
+		if key == nil {
+			return nil, fmt.Errorf("unknown key %s", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("invalid signing algorithm")
 		}
-		return []byte(manager.secretKey), nil
+		return key.VerifyKey, nil
 	})
 	if err != nil {
 		return nil, err
@@ -52,9 +296,65 @@ func (manager *manager) Parse(tokenString string) (*Claims, error) {
 	return nil, fmt.Errorf("invalid token")
 }
 
-func NewManager(secretKey string, expireTime time.Duration) Manager {
-	return &manager{
-		secretKey:  secretKey,
-		expireTime: expireTime,
+func (manager *manager) checkRevoked(claims *Claims) error {
+	if claims.ID != "" {
+		revoked, err := manager.store.Revoked(claims.ID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
+	}
+	return manager.checkSession(claims)
+}
+
+func (manager *manager) checkSession(claims *Claims) error {
+	if claims.Family != "" {
+		revoked, err := manager.store.Revoked(familyPrefix + claims.Family)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
+	}
+	before, err := manager.store.RevokedBefore(claims.Subject)
+	if err != nil {
+		return err
+	}
+	// IssuedAt only carries whole seconds, so the revocation time is compared
+	// at the same precision.
+	if claims.IssuedAt != nil && claims.IssuedAt.Before(before.Truncate(jwt.TimePrecision)) {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+func NewManager(secretKey string, expireTime time.Duration, options ...Option) Manager {
+	return NewKeyManager(expireTime, NewHMACKey("", secretKey), options...)
+}
+
+func NewKeyManager(expireTime time.Duration, signingKey *Key, options ...Option) Manager {
+	manager := &manager{
+		signingKey:   signingKey,
+		keys:         map[string]*Key{signingKey.ID: signingKey},
+		expireTime:   expireTime,
+		refreshTime:  defaultRefreshTime,
+		refreshGrace: defaultRefreshGrace,
+		refreshes:    map[string]*refreshResult{},
+	}
+	for _, option := range options {
+		option(manager)
 	}
+	if manager.store == nil {
+		manager.store = NewMemoryStore()
+	}
+	return manager
+}
+
+func newID() string {
+	bytes := make([]byte, 16)
+	_, _ = rand.Read(bytes)
+	return hex.EncodeToString(bytes)
 }
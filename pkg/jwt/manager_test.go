@@ -0,0 +1,236 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newECKey(t *testing.T, id string) *Key {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewKey(id, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKeyRotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, keyRing KeyRing)
+		wantErr bool
+	}{
+		{
+			name:  "same key",
+			setup: func(t *testing.T, keyRing KeyRing) {},
+		},
+		{
+			name: "rotated keeps previous key",
+			setup: func(t *testing.T, keyRing KeyRing) {
+				if err := keyRing.Rotate(newECKey(t, "v2")); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "rotated and previous key removed",
+			setup: func(t *testing.T, keyRing KeyRing) {
+				if err := keyRing.Rotate(newECKey(t, "v2")); err != nil {
+					t.Fatal(err)
+				}
+				keyRing.RemoveKey("v1")
+			},
+			wantErr: true,
+		},
+		{
+			name: "signing key can not be removed",
+			setup: func(t *testing.T, keyRing KeyRing) {
+				keyRing.RemoveKey("v1")
+			},
+		},
+		{
+			name: "public key can not sign",
+			setup: func(t *testing.T, keyRing KeyRing) {
+				signer := newECKey(t, "v2")
+				public, err := NewPublicKey("v2", signer.VerifyKey)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = keyRing.Rotate(public); err == nil {
+					t.Fatal("Rotate() with a public key succeeded")
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := NewKeyManager(time.Minute, newECKey(t, "v1"))
+			keyRing, ok := manager.(KeyRing)
+			if !ok {
+				t.Fatal("manager does not implement KeyRing")
+			}
+			token, _ := manager.Create("user", nil)
+			test.setup(t, keyRing)
+			if _, err := manager.Parse(token); (err != nil) != test.wantErr {
+				t.Fatalf("Parse() err = %v, wantErr %v", err, test.wantErr)
+			}
+			fresh, _ := manager.Create("user", nil)
+			if _, err := manager.Parse(fresh); err != nil {
+				t.Fatalf("Parse() of a new token: %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyLookup(t *testing.T) {
+	signing := newECKey(t, "v1")
+	unnamed := NewHMACKey("", "secret")
+	tests := []struct {
+		name string
+		keys []*Key
+		id   string
+		want *Key
+	}{
+		{name: "by id", id: "v1", want: signing},
+		{name: "empty id falls back to signing key", id: "", want: signing},
+		{name: "empty id prefers registered key", keys: []*Key{unnamed}, id: "", want: unnamed},
+		{name: "unknown id", id: "v9"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keyRing := NewKeyManager(time.Minute, signing, VerificationKeys(test.keys...)).(KeyRing)
+			if got := keyRing.Key(test.id); got != test.want {
+				t.Fatalf("Key(%q) = %v, want %v", test.id, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseUnnamedVerificationKey(t *testing.T) {
+	legacy := NewHMACKey("", "legacy")
+	token, _ := NewManager("legacy", time.Minute).Create("user", nil)
+	manager := NewKeyManager(time.Minute, newECKey(t, "v1"), VerificationKeys(legacy))
+	if _, err := manager.Parse(token); err != nil {
+		t.Fatalf("Parse() err = %v", err)
+	}
+	if _, err := manager.Parse(token + "x"); err == nil || errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("Parse() of a tampered token err = %v", err)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		grace    time.Duration
+		token    func(pair *TokenPair) string
+		before   func(manager Manager, pair *TokenPair)
+		parallel int
+		wantErr  error
+	}{
+		{name: "single refresh", parallel: 1},
+		{name: "concurrent refreshes share the pair", grace: time.Minute, parallel: 8},
+		{
+			name:    "access token",
+			token:   func(pair *TokenPair) string { return pair.AccessToken },
+			wantErr: ErrInvalidTokenType,
+		},
+		{
+			name:  "reuse after grace",
+			grace: time.Nanosecond,
+			before: func(manager Manager, pair *TokenPair) {
+				if _, err := manager.Refresh(pair.RefreshToken); err != nil {
+					t.Fatal(err)
+				}
+				time.Sleep(10 * time.Millisecond)
+			},
+			wantErr: ErrRefreshTokenReused,
+		},
+		{
+			name:  "revoked session",
+			grace: time.Minute,
+			before: func(manager Manager, pair *TokenPair) {
+				if _, err := manager.Refresh(pair.RefreshToken); err != nil {
+					t.Fatal(err)
+				}
+				if err := manager.Revoke(pair.Claims); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrTokenRevoked,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := NewManager("secret", time.Minute, RefreshGrace(test.grace))
+			pair, err := manager.CreatePair("user", map[string]any{"name": "a"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			token := pair.RefreshToken
+			if test.token != nil {
+				token = test.token(pair)
+			}
+			if test.before != nil {
+				test.before(manager, pair)
+			}
+			parallel := max(test.parallel, 1)
+			pairs := make([]*TokenPair, parallel)
+			errs := make([]error, parallel)
+			var group sync.WaitGroup
+			for i := range parallel {
+				group.Go(func() {
+					pairs[i], errs[i] = manager.Refresh(token)
+				})
+			}
+			group.Wait()
+			for i, err := range errs {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("Refresh() err = %v, want %v", err, test.wantErr)
+				}
+				if err != nil {
+					continue
+				}
+				if pairs[i].RefreshToken != pairs[0].RefreshToken {
+					t.Fatal("concurrent refreshes issued different pairs")
+				}
+				if _, err = manager.Parse(pairs[i].AccessToken); err != nil {
+					t.Fatalf("Parse() of the renewed token: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRevokeSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  func() time.Time
+		wantErr error
+	}{
+		{name: "issued in the revocation second", before: time.Now},
+		{name: "issued before the revocation", before: func() time.Time { return time.Now().Add(2 * time.Second) }, wantErr: ErrTokenRevoked},
+		{name: "issued after the revocation", before: func() time.Time { return time.Now().Add(-2 * time.Second) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			manager := NewManager("secret", time.Minute, Revocation(store))
+			if err := store.RevokeSubject("user", test.before()); err != nil {
+				t.Fatal(err)
+			}
+			token, _ := manager.Create("user", nil)
+			if _, err := manager.Parse(token); !errors.Is(err, test.wantErr) {
+				t.Fatalf("Parse() err = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
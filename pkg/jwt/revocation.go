@@ -0,0 +1,133 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/misakacoder/inuyasha/pkg/db/types"
+	"github.com/misakacoder/inuyasha/pkg/task"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+	"time"
+)
+
+const cleanupInterval = 10 * time.Minute
+
+type RevocationStore interface {
+	Revoke(id string, expiresAt time.Time) (bool, error)
+	Revoked(id string) (bool, error)
+	RevokeSubject(subject string, before time.Time) error
+	RevokedBefore(subject string) (time.Time, error)
+}
+
+type MemoryStore struct {
+	mutex    sync.RWMutex
+	ids      map[string]time.Time
+	subjects map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	store := &MemoryStore{ids: map[string]time.Time{}, subjects: map[string]time.Time{}}
+	task.Register(store.cleanup, cleanupInterval)
+	return store
+}
+
+func (store *MemoryStore) Revoke(id string, expiresAt time.Time) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if _, ok := store.ids[id]; ok {
+		return false, nil
+	}
+	store.ids[id] = expiresAt
+	return true, nil
+}
+
+func (store *MemoryStore) Revoked(id string) (bool, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	_, ok := store.ids[id]
+	return ok, nil
+}
+
+func (store *MemoryStore) RevokeSubject(subject string, before time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.subjects[subject] = before
+	return nil
+}
+
+func (store *MemoryStore) RevokedBefore(subject string) (time.Time, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.subjects[subject], nil
+}
+
+func (store *MemoryStore) cleanup() {
+	now := time.Now()
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	for id, expiresAt := range store.ids {
+		if now.After(expiresAt) {
+			delete(store.ids, id)
+		}
+	}
+}
+
+type RevokedToken struct {
+	ID        string         `gorm:"primaryKey;size:128"`
+	ExpiresAt types.DateTime `gorm:"index"`
+}
+
+func (RevokedToken) TableComment() string {
+	return "已吊销令牌"
+}
+
+type RevokedSubject struct {
+	Subject   string `gorm:"primaryKey;size:128"`
+	RevokedAt types.DateTime
+}
+
+func (RevokedSubject) TableComment() string {
+	return "已吊销用户令牌"
+}
+
+type DBStore struct {
+	db *gorm.DB
+}
+
+func NewDBStore(db *gorm.DB) (*DBStore, error) {
+	if err := db.AutoMigrate(&RevokedToken{}, &RevokedSubject{}); err != nil {
+		return nil, err
+	}
+	store := &DBStore{db: db}
+	task.Register(store.cleanup, cleanupInterval)
+	return store, nil
+}
+
+func (store *DBStore) Revoke(id string, expiresAt time.Time) (bool, error) {
+	result := store.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&RevokedToken{ID: id, ExpiresAt: types.DateTimeFrom(expiresAt)})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (store *DBStore) Revoked(id string) (bool, error) {
+	var count int64
+	err := store.db.Model(&RevokedToken{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+func (store *DBStore) RevokeSubject(subject string, before time.Time) error {
+	revoked := &RevokedSubject{Subject: subject, RevokedAt: types.DateTimeFrom(before)}
+	return store.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(revoked).Error
+}
+
+func (store *DBStore) RevokedBefore(subject string) (time.Time, error) {
+	var revoked RevokedSubject
+	err := store.db.Where("subject = ?", subject).Take(&revoked).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	return revoked.RevokedAt.Time(), err
+}
+
+func (store *DBStore) cleanup() {
+	store.db.Where("expires_at < ?", types.DateTimeNow()).Delete(&RevokedToken{})
+}
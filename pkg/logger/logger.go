@@ -50,25 +50,32 @@ func (receiver *DynamicLevelLogger) Push(level logger.Level, caller string, mess
 		_, file, line, _ := runtime.Caller(3)
 		caller = fmt.Sprintf("%s:%d", file, line)
 	}
-	i := strings.LastIndex(caller, ":")
+	receiver.push(level, caller, nil, message, args...)
+}
+
+func (receiver *DynamicLevelLogger) PushTables(level logger.Level, caller string, tables []string, message string, args ...any) {
+	if caller == "" {
+		_, file, line, _ := runtime.Caller(1)
+		caller = fmt.Sprintf("%s:%d", file, line)
+	}
+	receiver.push(level, caller, tables, message, args...)
+}
+
+func (receiver *DynamicLevelLogger) push(level logger.Level, caller string, tables []string, message string, args ...any) {
 	foundLevel := false
-	if value, ok := receiver.callerMap.Get(caller[0:i]); ok {
-		level = value
-		foundLevel = true
+	if i := strings.LastIndex(caller, ":"); i >= 0 {
+		if value, ok := receiver.callerMap.Get(caller[0:i]); ok {
+			level = value
+			foundLevel = true
+		}
 	}
 	if !foundLevel {
-		receiver.tableMap.Range(func(k string, v logger.Level) {
-			for _, arg := range args {
-				if foundLevel {
-					return
-				}
-				sql, ok := arg.(string)
-				if ok && strings.Contains(sql, fmt.Sprintf("`%s`", k)) {
-					level = v
-					foundLevel = true
-				}
+		for _, table := range tables {
+			if value, ok := receiver.tableMap.Get(table); ok {
+				level = value
+				break
 			}
-		})
+		}
 	}
 	receiver.SimpleLogger.Push(level, caller, message, args...)
 }
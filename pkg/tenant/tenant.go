@@ -0,0 +1,50 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+const DefaultColumn = "tenant_id"
+
+var ErrTenantMissing = errors.New("tenant id is missing")
+
+type tenantKey struct{}
+
+type ignoreKey struct{}
+
+type TenantScoped interface {
+	TenantColumn() string
+}
+
+type Model struct {
+	TenantID string `json:"tenantId" gorm:"column:tenant_id;size:64;index"`
+}
+
+func (model Model) TenantColumn() string {
+	return DefaultColumn
+}
+
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+func FromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+func Ignore(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ignoreKey{}, true)
+}
+
+func IsIgnored(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	ignored, _ := ctx.Value(ignoreKey{}).(bool)
+	return ignored
+}
@@ -0,0 +1,33 @@
+package enum
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/http/resp"
+	"github.com/misakacoder/inuyasha/pkg/enum"
+)
+
+// list              godoc
+// @Tags             枚举
+// @Summary          列表
+// @Router           /api/enums [get]
+// @Produce          json
+// @Success          200 {object} resp.Result
+func list(ctx *gin.Context) {
+	resp.OK.With(enum.Schemas()).Write(ctx)
+}
+
+// get               godoc
+// @Tags             枚举
+// @Summary          详情
+// @Router           /api/enums/{name} [get]
+// @Param            name path string true "枚举名称"
+// @Produce          json
+// @Success          200 {object} resp.Result
+func get(ctx *gin.Context) {
+	describer, ok := enum.Lookup(ctx.Param("name"))
+	if !ok {
+		resp.NotFound(ctx)
+		return
+	}
+	resp.OK.With(describer.Schema()).Write(ctx)
+}
@@ -0,0 +1,13 @@
+package enum
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func Register(engine *gin.Engine, middleware ...gin.HandlerFunc) {
+	enum := engine.Group("/api/enums", middleware...)
+	{
+		enum.GET("", list)
+		enum.GET("/:name", get)
+	}
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/http/resp"
+	"github.com/misakacoder/inuyasha/pkg/excel"
+)
+
+// status            godoc
+// @Tags             导出
+// @Summary          任务状态
+// @Router           /api/exports/{id} [get]
+// @Param            id path string true "任务ID"
+// @Produce          json
+// @Success          200 {object} resp.Result
+func status(ctx *gin.Context) {
+	job, ok := excel.Jobs().Get(ctx.Param("id"))
+	if !ok {
+		resp.NotFound(ctx)
+		return
+	}
+	resp.OK.With(job).Write(ctx)
+}
+
+// download          godoc
+// @Tags             导出
+// @Summary          下载文件
+// @Router           /api/exports/{id}/file [get]
+// @Param            id path string true "任务ID"
+// @Produce          octet-stream
+// @Success          200 {file} file
+func download(ctx *gin.Context) {
+	excel.Jobs().Download(ctx, ctx.Param("id"))
+}
+
+// remove            godoc
+// @Tags             导出
+// @Summary          删除任务
+// @Router           /api/exports/{id} [delete]
+// @Param            id path string true "任务ID"
+// @Produce          json
+// @Success          200 {object} resp.Result
+func remove(ctx *gin.Context) {
+	excel.Jobs().Remove(ctx.Param("id"))
+	resp.OK.Write(ctx)
+}
@@ -0,0 +1,14 @@
+package export
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func Register(engine *gin.Engine, middleware ...gin.HandlerFunc) {
+	export := engine.Group("/api/exports", middleware...)
+	{
+		export.GET("/:id", status)
+		export.GET("/:id/file", download)
+		export.DELETE("/:id", remove)
+	}
+}
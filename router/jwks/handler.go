@@ -0,0 +1,25 @@
+package jwks
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/pkg/jwt"
+	"net/http"
+)
+
+// jwks              godoc
+// @Tags             认证
+// @Summary          公钥集合
+// @Router           /.well-known/jwks.json [get]
+// @Produce          json
+// @Success          200 {object} jwt.JWKSet
+func jwks(manager jwt.Manager) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		keySet, ok := manager.(jwt.KeySet)
+		if !ok {
+			ctx.JSON(http.StatusOK, jwt.JWKSet{Keys: []jwt.JWK{}})
+			return
+		}
+		ctx.Header("Cache-Control", "public, max-age=300")
+		ctx.JSON(http.StatusOK, keySet.JWKS())
+	}
+}
@@ -0,0 +1,10 @@
+package jwks
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/pkg/jwt"
+)
+
+func Register(engine *gin.Engine, manager jwt.Manager) {
+	engine.GET("/.well-known/jwks.json", jwks(manager))
+}
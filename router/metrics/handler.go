@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/http/resp"
+	"github.com/misakacoder/inuyasha/pkg/db/metrics"
+)
+
+// dbStats           godoc
+// @Tags             监控
+// @Summary          数据库连接池及SQL统计
+// @Router           /api/metrics/db [get]
+// @Produce          json
+// @Success          200 {object} resp.Result
+func dbStats(ctx *gin.Context) {
+	resp.OK.With(metrics.Snapshot()).Write(ctx)
+}
+
+// prometheus        godoc
+// @Tags             监控
+// @Summary          Prometheus指标
+// @Router           /metrics [get]
+// @Produce          plain
+// @Success          200 {string} string
+func prometheus(ctx *gin.Context) {
+	ctx.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.WritePrometheus(ctx.Writer); err != nil {
+		_ = ctx.Error(err)
+	}
+}
@@ -0,0 +1,15 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func Register(engine *gin.Engine, middleware ...gin.HandlerFunc) {
+	metrics := engine.Group("/api/metrics", middleware...)
+	{
+		metrics.GET("/db", dbStats)
+	}
+	handlers := make([]gin.HandlerFunc, 0, len(middleware)+1)
+	handlers = append(handlers, middleware...)
+	engine.GET("/metrics", append(handlers, prometheus)...)
+}
@@ -0,0 +1,26 @@
+package slowlog
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/misakacoder/inuyasha/http/req"
+	"github.com/misakacoder/inuyasha/http/resp"
+	"github.com/misakacoder/inuyasha/model"
+	"github.com/misakacoder/inuyasha/pkg/db/slowlog"
+)
+
+// list              godoc
+// @Tags             慢SQL
+// @Summary          列表
+// @Router           /api/slowlog [get]
+// @Param            sort query string false "排序方式"
+// @Param            group query string false "分组方式"
+// @Produce          json
+// @Success          200 {object} resp.Result
+func list(ctx *gin.Context) {
+	ro := req.BindQuery(ctx, &model.SlowQuery{})
+	if ro.Group == "fingerprint" {
+		resp.OK.With(slowlog.Groups(ro.Sort)).Write(ctx)
+	} else {
+		resp.OK.With(slowlog.Records(ro.Sort)).Write(ctx)
+	}
+}
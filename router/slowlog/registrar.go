@@ -0,0 +1,12 @@
+package slowlog
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func Register(engine *gin.Engine, middleware ...gin.HandlerFunc) {
+	slowlog := engine.Group("/api/slowlog", middleware...)
+	{
+		slowlog.GET("", list)
+	}
+}